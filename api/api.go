@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/umarbektokyo/matetra-engine/engine"
 	"github.com/umarbektokyo/matetra-engine/model"
@@ -23,10 +25,64 @@ type PlayerPayload struct {
 	Hash string `json:"hash"`
 }
 
+type PlayerAddedPayload struct {
+	GameID   string `json:"game_id"`
+	Name     string `json:"name"`
+	PlayerID int    `json:"player_id"`
+	Rejoined bool   `json:"rejoined"`
+	Token    string `json:"token"` // presented in RESUME to get the seat back after a disconnect
+}
+
+type ReadyPayload struct {
+	Ready bool `json:"ready"`
+}
+
 type CardPlayPayload struct {
-	CardIndex int   `json:"card_index"`
-	Inputs    []int `json:"inputs"`
-	Permanent bool  `json:"permanent"`
+	CardIndex int         `json:"card_index"`
+	Inputs    []PlayInput `json:"inputs"`
+	Permanent bool        `json:"permanent"`
+}
+
+// One input of a card play, a number or the ID of a card ("ADD-3") for a c input
+type PlayInput struct {
+	Value  int
+	CardID string
+}
+
+func (in PlayInput) MarshalJSON() ([]byte, error) {
+	if in.CardID != "" {
+		return json.Marshal(in.CardID)
+	}
+	return json.Marshal(in.Value)
+}
+
+func (in *PlayInput) UnmarshalJSON(data []byte) error {
+	*in = PlayInput{}
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &in.CardID)
+	}
+	return json.Unmarshal(data, &in.Value)
+}
+
+// Turns the inputs into numbers, a card ID becomes its card's index
+func cardInputs(game *engine.Game, inputs []PlayInput) ([]int, error) {
+	values := make([]int, len(inputs))
+	for i, in := range inputs {
+		if in.CardID == "" {
+			values[i] = in.Value
+			continue
+		}
+		index, err := game.CardIndex(in.CardID)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+		values[i] = index
+	}
+	return values, nil
+}
+
+type CardUnplayPayload struct {
+	CardIndex int `json:"card_index"`
 }
 
 type CardPlayReply struct {
@@ -35,22 +91,35 @@ type CardPlayReply struct {
 	NewGameState *model.GameState `json:"newGameState,omitempty"`
 }
 
+type GameOverPayload struct {
+	Winners   []int            `json:"winners"`
+	Standings []model.Standing `json:"standings"`
+}
+
 type PlayerConnection struct {
 	conn     *websocket.Conn
 	mu       sync.Mutex
 	PlayerID int
+	Room     *Room // game the connection is seated in, nil before joining
 }
 
 type API struct {
-	Game        *engine.Game
+	Rooms       map[string]*Room
+	DefaultRoom string         // game joined by ADD_PLAYER, the first one created
+	Defaults    model.Settings // every new game starts with these settings
 	Connections map[int]*PlayerConnection
+	sessions    map[string]session // session token -> seat
+	journalPath string             // see SetJournal
 	nextConnID  int
+	mu          sync.Mutex // guards Rooms, DefaultRoom, Connections, sessions, nextConnID and every PlayerID and Room
 }
 
-func New(game *engine.Game) *API {
+func New(defaults model.Settings) *API {
 	return &API{
-		Game:        game,
+		Rooms:       make(map[string]*Room),
+		Defaults:    defaults,
 		Connections: make(map[int]*PlayerConnection),
+		sessions:    make(map[string]session),
 	}
 }
 
@@ -66,6 +135,16 @@ var upgrader = websocket.Upgrader{
 // starts the server + endpoints
 func (a *API) Start() {
 	http.HandleFunc("/ws", a.handleWebSocket)
+	go a.janitor()
+
+	// bots of restored games pick up where they stopped
+	a.mu.Lock()
+	for _, room := range a.Rooms {
+		if len(room.bots) > 0 {
+			go a.runBots(room)
+		}
+	}
+	a.mu.Unlock()
 
 	log.Println("API running on :1729")
 	log.Fatal(http.ListenAndServe(":1729", nil))
@@ -79,9 +158,11 @@ func (a *API) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	playerConn := &PlayerConnection{conn: conn, PlayerID: -1}
+	a.mu.Lock()
 	connID := a.nextConnID
 	a.Connections[connID] = playerConn
 	a.nextConnID++
+	a.mu.Unlock()
 
 	log.Printf("client %d connected", connID)
 
@@ -90,6 +171,8 @@ func (a *API) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 func (a *API) readMessages(connID int, pc *PlayerConnection) {
 	defer func() {
+		// the seat stays, the player can come back with RESUME
+		a.removeConnection(connID)
 		pc.conn.Close()
 		log.Printf("client %d disconnected", connID)
 	}()
@@ -107,7 +190,32 @@ func (a *API) readMessages(connID int, pc *PlayerConnection) {
 	}
 }
 
+// Messages that can change the turn or the state, the bots of the room get
+// to act after them
+var botTriggers = map[string]bool{
+	"ADD_BOT":           true,
+	"READY":             true,
+	"START_GAME":        true,
+	"PLAY_CARD":         true,
+	"UNPLAY_CARD":       true,
+	"PROCESS_NEXT_TURN": true,
+	"ROLL_DICE":         true,
+}
+
 func (a *API) handleIncomingMessages(pc *PlayerConnection, msg Message) {
+	defer func() {
+		a.mu.Lock()
+		a.touch(pc)
+		room := pc.Room
+		hasBots := room != nil && len(room.bots) > 0
+		a.mu.Unlock()
+
+		// bots answer whatever the message changed
+		if hasBots && botTriggers[msg.Type] {
+			go a.runBots(room)
+		}
+	}()
+
 	switch msg.Type {
 	case "ADD_PLAYER":
 		var payload PlayerPayload
@@ -119,19 +227,63 @@ func (a *API) handleIncomingMessages(pc *PlayerConnection, msg Message) {
 			a.sendError(pc, "invalid player payload format")
 			return
 		}
-
-		playerID, err := a.Game.AddPlayer(payload.Name, payload.Hash)
+		// joins the default game
+		a.joinGame(pc, "", payload)
+	case "CREATE_GAME":
+		var payload CreateGamePayload
+		payloadBytes, err := json.Marshal(msg.Payload)
+		if err != nil {
+			log.Printf("error marshalling payload: %v", err)
+		}
+		if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+			a.sendError(pc, "invalid create game payload format")
+			return
+		}
+		if payload.Hash == "" {
+			a.sendError(pc, "a password is required")
+			return
+		}
+		room, err := a.CreateGame(payload.Title, time.Now().UnixNano())
 		if err != nil {
 			a.sendError(pc, err.Error())
 			return
 		}
-
-		pc.PlayerID = playerID
-
-		a.sendResponse(pc, "PLAYER_ADDED", map[string]string{"name": payload.Name})
-		a.BroadcastState()
+		// the creator hosts the game
+		a.joinGame(pc, room.ID, PlayerPayload{Name: payload.Name, Hash: payload.Hash})
+	case "JOIN_GAME":
+		var payload JoinGamePayload
+		payloadBytes, err := json.Marshal(msg.Payload)
+		if err != nil {
+			log.Printf("error marshalling payload: %v", err)
+		}
+		if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+			a.sendError(pc, "invalid join game payload format")
+			return
+		}
+		a.joinGame(pc, payload.GameID, PlayerPayload{Name: payload.Name, Hash: payload.Hash})
+	case "LIST_GAMES":
+		a.sendResponse(pc, "GAMES", a.ListGames())
+	case "ADD_BOT":
+		a.handleAddBot(pc, msg.Payload)
+	case "RESUME":
+		a.handleResume(pc, msg.Payload)
+	case "READY":
+		a.handleReady(pc, msg.Payload)
+	case "UPDATE_SETTINGS":
+		a.handleUpdateSettings(pc, msg.Payload)
+	case "START_GAME":
+		a.handleStartGame(pc)
+	case "LIST_PACKS":
+		packs, err := engine.AvailablePacks()
+		if err != nil {
+			a.sendError(pc, err.Error())
+			return
+		}
+		a.sendResponse(pc, "PACKS", packs)
 	case "PLAY_CARD":
 		a.handlePlayCard(pc, msg.Payload)
+	case "UNPLAY_CARD":
+		a.handleUnplayCard(pc, msg.Payload)
 	case "PROCESS_NEXT_TURN":
 		a.handleNextTurn(pc)
 	case "ROLL_DICE":
@@ -158,10 +310,16 @@ func (a *API) handlePlayCard(pc *PlayerConnection, payload interface{}) {
 		return
 	}
 
-	resultState, err := a.Game.ProcessMove(
+	inputs, err := cardInputs(pc.Room.Game, cardPayload.Inputs)
+	if err != nil {
+		a.sendCustomReply(pc, false, fmt.Sprintf("move failed: %v", err), nil)
+		return
+	}
+
+	resultState, err := pc.Room.Game.ProcessMove(
 		pc.PlayerID,
 		cardPayload.CardIndex,
-		cardPayload.Inputs,
+		inputs,
 		cardPayload.Permanent,
 	)
 
@@ -175,37 +333,47 @@ func (a *API) handlePlayCard(pc *PlayerConnection, payload interface{}) {
 		playerName := resultState.Players[pc.PlayerID].Name
 		cardName := resultState.Cards[cardPayload.CardIndex].Name
 		message = fmt.Sprintf("@%s used %s!", playerName, cardName)
-		a.BroadcastReply(true, message, resultState)
+		// the preview is the mover's own, everybody else gets the live state
+		a.BroadcastReply(pc.Room, true, message, pc.Room.Game.CopyState())
 		message = "permanent move recorded successfully"
 	}
 
 	a.sendCustomReply(pc, true, message, resultState)
 }
 
-func (a *API) sendCustomReply(pc *PlayerConnection, success bool, message string, state *model.GameState) {
-	reply := CardPlayReply{
-		Success:      success,
-		Message:      message,
-		NewGameState: state,
+func (a *API) handleUnplayCard(pc *PlayerConnection, payload interface{}) {
+	if pc.PlayerID == -1 {
+		a.sendCustomReply(pc, false, "player is not authenticated", nil)
+		return
 	}
 
-	respMsg := Message{
-		Type:    "PLAY_CARD_REPLY",
-		Payload: reply,
+	var unplayPayload CardUnplayPayload
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		a.sendCustomReply(pc, false, "error parsing card unplay payload", nil)
+		return
+	}
+	if err := json.Unmarshal(payloadBytes, &unplayPayload); err != nil {
+		a.sendCustomReply(pc, false, "invalid card unplay payload format", nil)
+		return
 	}
 
-	pc.mu.Lock()
-	if err := pc.conn.WriteJSON(respMsg); err != nil {
-		log.Printf("error sending custom play card reply: %v", err)
+	resultState, err := pc.Room.Game.WithdrawCard(pc.PlayerID, unplayPayload.CardIndex)
+	if err != nil {
+		a.sendCustomReply(pc, false, fmt.Sprintf("withdraw failed: %v", err), nil)
+		return
 	}
-	pc.mu.Unlock()
+
+	playerName := resultState.Players[pc.PlayerID].Name
+	cardName := resultState.Cards[unplayPayload.CardIndex].Name
+	a.BroadcastReply(pc.Room, true, fmt.Sprintf("@%s took back %s!", playerName, cardName), resultState)
 }
 
-func (a *API) BroadcastReply(success bool, message string, state *model.GameState) {
+func (a *API) sendCustomReply(pc *PlayerConnection, success bool, message string, state *model.GameState) {
 	reply := CardPlayReply{
 		Success:      success,
 		Message:      message,
-		NewGameState: state,
+		NewGameState: engine.ViewFor(state, pc.PlayerID),
 	}
 
 	respMsg := Message{
@@ -213,39 +381,56 @@ func (a *API) BroadcastReply(success bool, message string, state *model.GameStat
 		Payload: reply,
 	}
 
-	for _, pc := range a.Connections {
-		pc.mu.Lock()
-		if err := pc.conn.WriteJSON(respMsg); err != nil {
+	if err := pc.writeJSON(respMsg); err != nil {
+		log.Printf("error sending custom play card reply: %v", err)
+	}
+}
+
+// Every connection gets its own view of the state
+func (a *API) BroadcastReply(room *Room, success bool, message string, state *model.GameState) {
+	for _, pc := range a.roomConnections(room) {
+		respMsg := Message{
+			Type: "PLAY_CARD_REPLY",
+			Payload: CardPlayReply{
+				Success:      success,
+				Message:      message,
+				NewGameState: engine.ViewFor(state, a.playerOf(pc)),
+			},
+		}
+
+		if err := pc.writeJSON(respMsg); err != nil {
 			log.Printf("error broadcasting reply: %v", err)
 		}
-		pc.mu.Unlock()
 	}
 }
 
-func (a *API) BroadcastState() {
-	stateMsg := Message{
-		Type:    "STATE_UPDATE",
-		Payload: a.Game.CopyState(),
-	}
-	for _, pc := range a.Connections {
-		pc.mu.Lock()
-		if err := pc.conn.WriteJSON(stateMsg); err != nil {
+func (a *API) BroadcastState(room *Room) {
+	state := room.Game.CopyState()
+	for _, pc := range a.roomConnections(room) {
+		stateMsg := Message{
+			Type:    "STATE_UPDATE",
+			Payload: engine.ViewFor(state, a.playerOf(pc)),
+		}
+
+		if err := pc.writeJSON(stateMsg); err != nil {
 			log.Printf("error broadcasting state: %v", err)
 		}
-		pc.mu.Unlock()
 	}
 }
 
+// Sends the current state to a single connection
+func (a *API) sendState(pc *PlayerConnection) {
+	a.sendResponse(pc, "STATE_UPDATE", engine.ViewFor(pc.Room.Game.CopyState(), pc.PlayerID))
+}
+
 func (a *API) sendResponse(pc *PlayerConnection, responseType string, data interface{}) {
 	respMsg := Message{
 		Type:    responseType,
 		Payload: data,
 	}
-	pc.mu.Lock()
-	if err := pc.conn.WriteJSON(respMsg); err != nil {
+	if err := pc.writeJSON(respMsg); err != nil {
 		log.Printf("error sending response: %v", err)
 	}
-	pc.mu.Unlock()
 }
 
 func (a *API) sendError(pc *PlayerConnection, errMsg string) {
@@ -255,11 +440,9 @@ func (a *API) sendError(pc *PlayerConnection, errMsg string) {
 			"message": errMsg,
 		},
 	}
-	pc.mu.Lock()
-	if err := pc.conn.WriteJSON(errorMsg); err != nil {
+	if err := pc.writeJSON(errorMsg); err != nil {
 		log.Printf("error sending error: %v", err)
 	}
-	pc.mu.Unlock()
 }
 
 func (a *API) handleNextTurn(pc *PlayerConnection) {
@@ -268,17 +451,61 @@ func (a *API) handleNextTurn(pc *PlayerConnection) {
 		return
 	}
 
-	resultState, err := a.Game.ProcessNextTurn(pc.PlayerID)
+	resultState, err := pc.Room.Game.ProcessNextTurn(pc.PlayerID)
 	if err != nil {
 		a.sendCustomReply(pc, false, fmt.Sprintf("failed to end the turn: %v", err), nil)
 		return
 	}
 
-	message := fmt.Sprintf("player @%s has ended their turn.", resultState.Players[pc.PlayerID].Name)
-	if resultState.Turn != a.Game.State.Turn {
+	a.BroadcastReply(pc.Room, true, turnEndedMessage(pc.PlayerID, resultState), resultState)
+
+	if resultState.Phase == model.PhaseFinished {
+		a.BroadcastGameOver(pc.Room, resultState)
+	}
+}
+
+// Announces the end of a player's turn, and the resolved queue once the turn advanced
+func turnEndedMessage(playerID int, resultState *model.GameState) string {
+	message := fmt.Sprintf("player @%s has ended their turn.", resultState.Players[playerID].Name)
+	// everyone is active again once the turn has advanced
+	if !resultState.Done[playerID] {
 		message = fmt.Sprintf("turn finished! started turn %d. current player is @%s", resultState.Turn, resultState.Players[resultState.Turn%len(resultState.Players)].Name)
+		if len(resultState.Resolved) > 0 {
+			names := make([]string, len(resultState.Resolved))
+			failures := []string{}
+			for i, r := range resultState.Resolved {
+				names[i] = resultState.Cards[r.Card].Name
+				if r.Outcome == model.OutcomeCountered {
+					failures = append(failures, fmt.Sprintf("@%s's %s was countered", resultState.Players[r.Owner].Name, names[i]))
+				}
+				if r.Outcome != model.OutcomeApplied && r.Error != "" {
+					failures = append(failures, fmt.Sprintf("@%s's %s failed (%s): %s",
+						resultState.Players[r.Owner].Name, names[i], strings.ToLower(r.Outcome), r.Error))
+				}
+			}
+			message += fmt.Sprintf(". resolved: %s", strings.Join(names, " -> "))
+			if len(failures) > 0 {
+				message += ". " + strings.Join(failures, ". ")
+			}
+		}
+	}
+	return message
+}
+
+func (a *API) BroadcastGameOver(room *Room, state *model.GameState) {
+	respMsg := Message{
+		Type: "GAME_OVER",
+		Payload: GameOverPayload{
+			Winners:   state.Winners,
+			Standings: state.Standings,
+		},
+	}
+
+	for _, pc := range a.roomConnections(room) {
+		if err := pc.writeJSON(respMsg); err != nil {
+			log.Printf("error broadcasting game over: %v", err)
+		}
 	}
-	a.BroadcastReply(true, message, resultState)
 }
 
 func (a *API) handleRollDice(pc *PlayerConnection) {
@@ -287,7 +514,7 @@ func (a *API) handleRollDice(pc *PlayerConnection) {
 		return
 	}
 
-	resultState, err := a.Game.ProcessDiceRoll(pc.PlayerID)
+	resultState, err := pc.Room.Game.ProcessDiceRoll(pc.PlayerID)
 	if err != nil {
 		a.sendCustomReply(pc, false, fmt.Sprintf("Dice roll failed: %v", err), nil)
 		return
@@ -296,5 +523,78 @@ func (a *API) handleRollDice(pc *PlayerConnection) {
 	playerName := resultState.Players[pc.PlayerID].Name
 	message := fmt.Sprintf("@%s rolled the dice!", playerName)
 
-	a.BroadcastReply(true, message, resultState)
+	a.BroadcastReply(pc.Room, true, message, resultState)
+}
+
+func (a *API) handleReady(pc *PlayerConnection, payload interface{}) {
+	if pc.PlayerID == -1 {
+		a.sendError(pc, "not authenticated")
+		return
+	}
+
+	var readyPayload ReadyPayload
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		a.sendError(pc, "error parsing ready payload")
+		return
+	}
+	if err := json.Unmarshal(payloadBytes, &readyPayload); err != nil {
+		a.sendError(pc, "invalid ready payload format")
+		return
+	}
+
+	resultState, err := pc.Room.Game.SetReady(pc.PlayerID, readyPayload.Ready)
+	if err != nil {
+		a.sendCustomReply(pc, false, fmt.Sprintf("ready check failed: %v", err), nil)
+		return
+	}
+
+	message := fmt.Sprintf("@%s is ready!", resultState.Players[pc.PlayerID].Name)
+	if !readyPayload.Ready {
+		message = fmt.Sprintf("@%s is not ready.", resultState.Players[pc.PlayerID].Name)
+	}
+	a.BroadcastReply(pc.Room, true, message, resultState)
+}
+
+func (a *API) handleUpdateSettings(pc *PlayerConnection, payload interface{}) {
+	if pc.PlayerID == -1 {
+		a.sendError(pc, "not authenticated")
+		return
+	}
+
+	// only the fields present in the payload are changed
+	settings := pc.Room.Game.CopyState().Settings
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		a.sendError(pc, "error parsing settings payload")
+		return
+	}
+	if err := json.Unmarshal(payloadBytes, &settings); err != nil {
+		a.sendError(pc, "invalid settings payload format")
+		return
+	}
+
+	resultState, err := pc.Room.Game.UpdateSettings(pc.PlayerID, settings)
+	if err != nil {
+		a.sendCustomReply(pc, false, fmt.Sprintf("failed to update settings: %v", err), nil)
+		return
+	}
+
+	a.BroadcastReply(pc.Room, true, "settings updated, please ready up again.", resultState)
+}
+
+func (a *API) handleStartGame(pc *PlayerConnection) {
+	if pc.PlayerID == -1 {
+		a.sendError(pc, "not authenticated")
+		return
+	}
+
+	resultState, err := pc.Room.Game.StartGame(pc.PlayerID)
+	if err != nil {
+		a.sendCustomReply(pc, false, fmt.Sprintf("failed to start the game: %v", err), nil)
+		return
+	}
+
+	message := fmt.Sprintf("the game has started! current player is @%s", resultState.Players[0].Name)
+	a.BroadcastReply(pc.Room, true, message, resultState)
 }
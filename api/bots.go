@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/umarbektokyo/matetra-engine/bot"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+type AddBotPayload struct {
+	Strategy string `json:"strategy"` // random or greedy, see bot.Strategies
+	Name     string `json:"name"`     // defaults to the strategy and seat
+}
+
+// Seats a bot in the sender's game, only in the lobby
+func (a *API) handleAddBot(pc *PlayerConnection, payload interface{}) {
+	if pc.PlayerID == -1 {
+		a.sendError(pc, "not authenticated")
+		return
+	}
+
+	var botPayload AddBotPayload
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		a.sendError(pc, "error parsing bot payload")
+		return
+	}
+	if err := json.Unmarshal(payloadBytes, &botPayload); err != nil {
+		a.sendError(pc, "invalid bot payload format")
+		return
+	}
+
+	room := pc.Room
+	if botPayload.Strategy == "" {
+		botPayload.Strategy = bot.StrategyGreedy
+	}
+	if botPayload.Name == "" {
+		seats := len(room.Game.CopyState().Players)
+		botPayload.Name = fmt.Sprintf("%s-bot-%d", botPayload.Strategy, seats)
+	}
+
+	b, err := bot.Join(room.Game, botPayload.Name, botPayload.Strategy, time.Now().UnixNano())
+	if err != nil {
+		a.sendCustomReply(pc, false, fmt.Sprintf("failed to add a bot: %v", err), nil)
+		return
+	}
+
+	a.mu.Lock()
+	room.bots = append(room.bots, b)
+	a.mu.Unlock()
+
+	state := room.Game.CopyState()
+	message := fmt.Sprintf("@%s (%s bot) joined the game!", botPayload.Name, b.Kind)
+	a.BroadcastReply(room, true, message, state)
+}
+
+// Lets the bots of the room act until none of them has anything left to do
+func (a *API) runBots(room *Room) {
+	room.botMu.Lock()
+	defer room.botMu.Unlock()
+
+	for {
+		a.mu.Lock()
+		bots := append([]*bot.Bot(nil), room.bots...)
+		a.mu.Unlock()
+
+		state := room.Game.CopyState()
+		var due *bot.Bot
+		for _, b := range bots {
+			if b.Due(state) {
+				due = b
+				break
+			}
+		}
+		if due == nil {
+			return
+		}
+
+		err := due.Act(room.Game, func(action bot.Action) {
+			a.reportBot(room, due, action)
+		})
+		if err != nil {
+			log.Printf("bot @%s in game %s: %v", state.Players[due.Player].Name, room.ID, err)
+			return
+		}
+	}
+}
+
+// Broadcasts a bot's step like the matching message from a player
+func (a *API) reportBot(room *Room, b *bot.Bot, action bot.Action) {
+	state := action.State
+	name := state.Players[b.Player].Name
+	var message string
+	switch action.Type {
+	case bot.ActionReady:
+		message = fmt.Sprintf("@%s is ready!", name)
+	case bot.ActionRoll:
+		message = fmt.Sprintf("@%s rolled the dice!", name)
+	case bot.ActionPlay:
+		message = fmt.Sprintf("@%s used %s!", name, state.Cards[action.Play.Card].Name)
+	case bot.ActionEnd:
+		message = turnEndedMessage(b.Player, state)
+	}
+	a.BroadcastReply(room, true, message, state)
+
+	if state.Phase == model.PhaseFinished {
+		a.BroadcastGameOver(room, state)
+	}
+}
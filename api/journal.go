@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Writes the journal of every game created from now on to its own file next
+// to path, game.jsonl becomes game.<game id>.jsonl
+func (a *API) SetJournal(path string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.journalPath = path
+}
+
+// Journal file of a game, see SetJournal
+func journalFile(path, gameID string) string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	return base + "." + gameID + ".jsonl"
+}
+
+// Internal version (no lock), starts writing the room's journal
+func (a *API) openJournal(room *Room) error {
+	if a.journalPath == "" {
+		return nil
+	}
+	path := journalFile(a.journalPath, room.ID)
+	journal, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	room.journal = journal
+	room.Game.SetJournalWriter(journal)
+	log.Printf("writing the journal of game %s to %s", room.ID, path)
+	return nil
+}
+
+// Internal version (no lock), appends to the journal of a restored room. Events
+// the file is missing are written first. Events past the save, left by a
+// crash, are cut off, the game goes on from the save.
+func (a *API) reopenJournal(room *Room) error {
+	if a.journalPath == "" {
+		return nil
+	}
+	path := journalFile(a.journalPath, room.ID)
+	journal, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(journal)
+	if err != nil {
+		journal.Close()
+		return err
+	}
+
+	events := len(room.Game.Journal())
+	written, size := 0, 0
+	for written < events {
+		end := bytes.IndexByte(data[size:], '\n')
+		if end == -1 {
+			break
+		}
+		size += end + 1
+		written++
+	}
+	if size < len(data) {
+		log.Printf("journal of game %s has events past the save, cutting it at event %d", room.ID, written)
+		if err := journal.Truncate(int64(size)); err != nil {
+			journal.Close()
+			return err
+		}
+	}
+
+	room.journal = journal
+	room.Game.ResumeJournalWriter(journal, written)
+	log.Printf("writing the journal of game %s to %s", room.ID, path)
+	return nil
+}
+
+// Internal version (no lock), flushes the room's journal to disk and closes it
+func (room *Room) closeJournal() {
+	if room.journal == nil {
+		return
+	}
+	room.Game.SetJournalWriter(nil)
+	if err := room.journal.Sync(); err != nil {
+		log.Printf("failed to flush the journal of game %s: %v", room.ID, err)
+	}
+	if err := room.journal.Close(); err != nil {
+		log.Printf("failed to close the journal of game %s: %v", room.ID, err)
+	}
+	room.journal = nil
+}
+
+// Flushes and closes the journal of every game, call it before the server exits
+func (a *API) CloseJournals() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, room := range a.Rooms {
+		room.closeJournal()
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Every event in the journal file
+func readJournal(t *testing.T, path string) []model.Event {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("no journal: %v", err)
+	}
+	defer file.Close()
+
+	var events []model.Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e model.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestJournalPerGame(t *testing.T) {
+	dir := t.TempDir()
+	a := New(engine.DefaultSettings())
+	a.SetJournal(filepath.Join(dir, "game.jsonl"))
+
+	first, err := a.CreateGame("first", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := a.CreateGame("second", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := second.Game.AddPlayer("ann", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	a.CloseJournals()
+
+	for _, room := range []*Room{first, second} {
+		events := readJournal(t, filepath.Join(dir, "game."+room.ID+".jsonl"))
+		if len(events) == 0 || events[0].Type != model.EventGameCreated || events[0].Name != room.ID {
+			t.Errorf("journal of game %s starts with %+v", room.ID, events)
+		}
+		if room == second && events[len(events)-1].Type != model.EventPlayerJoined {
+			t.Errorf("journal of game %s is missing the join", room.ID)
+		}
+	}
+
+	// a closed journal is no longer written to
+	path := filepath.Join(dir, "game."+second.ID+".jsonl")
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := second.Game.AddPlayer("bob", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() != before.Size() {
+		t.Errorf("the closed journal of game %s grew from %d to %d bytes", second.ID, before.Size(), after.Size())
+	}
+}
+
+// A resumed game carries on in its journal from the save, events recorded
+// after the save are lost with the crash and cut off
+func TestJournalAfterRestore(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, "game.jsonl")
+	save := filepath.Join(dir, "games.json")
+
+	a := New(engine.DefaultSettings())
+	a.SetJournal(journal)
+	room, err := a.CreateGame("game", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := room.Game.AddPlayer("ann", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Save(save); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := room.Game.AddPlayer("lost", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	a.CloseJournals()
+
+	restored, err := Restore(save, journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := restored.Rooms[room.ID].Game.AddPlayer("bob", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	restored.CloseJournals()
+
+	events := readJournal(t, journalFile(journal, room.ID))
+	names := []string{}
+	for i, e := range events {
+		if e.Seq != i {
+			t.Errorf("event %d has seq %d", i, e.Seq)
+		}
+		if e.Type == model.EventPlayerJoined {
+			names = append(names, e.Name)
+		}
+	}
+	if strings.Join(names, ",") != "ann,bob" {
+		t.Errorf("the journal has the joins %v, want ann,bob", names)
+	}
+}
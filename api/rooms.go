@@ -0,0 +1,275 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/umarbektokyo/matetra-engine/bot"
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Finished games and games nobody is connected to are closed once they have
+// been idle for this long
+const (
+	finishedRoomTimeout  = 10 * time.Minute
+	abandonedRoomTimeout = 30 * time.Minute
+	janitorInterval      = time.Minute
+)
+
+// One game hosted by the server
+type Room struct {
+	ID         string
+	Title      string
+	Game       *engine.Game
+	Created    time.Time
+	lastActive time.Time  // guarded by API.mu
+	bots       []*bot.Bot // guarded by API.mu
+	journal    *os.File   // guarded by API.mu, nil without a journal
+	botMu      sync.Mutex // one bot acts at a time
+}
+
+type CreateGamePayload struct {
+	Title string `json:"title"`
+	Name  string `json:"name"`
+	Hash  string `json:"hash"`
+}
+
+type JoinGamePayload struct {
+	GameID string `json:"game_id"`
+	Name   string `json:"name"`
+	Hash   string `json:"hash"`
+}
+
+type GameInfo struct {
+	GameID  string   `json:"game_id"`
+	Title   string   `json:"title"`
+	Phase   string   `json:"phase"`
+	Turn    int      `json:"turn"`
+	Players []string `json:"players"`
+}
+
+// Short code players type to join a game
+func newGameID() string {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("failed to generate game id: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Hosts a new game configured with the server defaults
+func (a *API) CreateGame(title string, seed int64) (*Room, error) {
+	if title == "" {
+		title = "Wonderful Game"
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	id := newGameID()
+	for a.Rooms[id] != nil {
+		id = newGameID()
+	}
+
+	settings := a.Defaults
+	settings.Victory = append([]model.VictoryCondition(nil), a.Defaults.Victory...)
+	settings.Packs = append([]string(nil), a.Defaults.Packs...)
+
+	game := engine.NewSeeded(id, seed)
+	if err := game.Configure(settings); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	room := &Room{ID: id, Title: title, Game: game, Created: now, lastActive: now}
+	if err := a.openJournal(room); err != nil {
+		return nil, err
+	}
+	a.Rooms[id] = room
+	if a.DefaultRoom == "" {
+		a.DefaultRoom = id
+	}
+	log.Printf("game %s (%s) created with seed %d", id, title, seed)
+	return room, nil
+}
+
+// Every hosted game, oldest first
+func (a *API) ListGames() []GameInfo {
+	a.mu.Lock()
+	rooms := make([]*Room, 0, len(a.Rooms))
+	for _, room := range a.Rooms {
+		rooms = append(rooms, room)
+	}
+	a.mu.Unlock()
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].Created.Before(rooms[j].Created)
+	})
+
+	games := make([]GameInfo, len(rooms))
+	for i, room := range rooms {
+		state := room.Game.CopyState()
+		players := make([]string, len(state.Players))
+		for j, p := range state.Players {
+			players[j] = p.Name
+		}
+		games[i] = GameInfo{
+			GameID:  room.ID,
+			Title:   room.Title,
+			Phase:   state.Phase,
+			Turn:    state.Turn,
+			Players: players,
+		}
+	}
+	return games
+}
+
+// Returns the room with the id, the default room for an empty id
+func (a *API) room(gameID string) (*Room, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if gameID == "" {
+		if a.DefaultRoom == "" {
+			return nil, fmt.Errorf("there is no default game, create or join one")
+		}
+		gameID = a.DefaultRoom
+	}
+	room := a.Rooms[gameID]
+	if room == nil {
+		return nil, fmt.Errorf("there is no game %s", gameID)
+	}
+	return room, nil
+}
+
+// Takes a seat in the game, or gets it back when the name and hash match
+func (a *API) joinGame(pc *PlayerConnection, gameID string, payload PlayerPayload) {
+	if payload.Hash == "" {
+		a.sendError(pc, "a password is required")
+		return
+	}
+
+	room, err := a.room(gameID)
+	if err != nil {
+		a.sendError(pc, err.Error())
+		return
+	}
+
+	playerID, rejoined, err := room.Game.AddPlayer(payload.Name, payload.Hash)
+	if err != nil {
+		a.sendError(pc, err.Error())
+		return
+	}
+
+	token := a.bindPlayer(pc, room, playerID)
+
+	a.sendResponse(pc, "PLAYER_ADDED", PlayerAddedPayload{
+		GameID:   room.ID,
+		Name:     payload.Name,
+		PlayerID: playerID,
+		Rejoined: rejoined,
+		Token:    token,
+	})
+	if rejoined {
+		a.sendState(pc)
+		return
+	}
+	a.BroadcastState(room)
+}
+
+// Connections seated in the room, safe to use without holding the lock
+func (a *API) roomConnections(room *Room) []*PlayerConnection {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	conns := []*PlayerConnection{}
+	for _, pc := range a.Connections {
+		if pc.Room == room {
+			conns = append(conns, pc)
+		}
+	}
+	return conns
+}
+
+// Internal version (no lock)
+func (a *API) touch(pc *PlayerConnection) {
+	if pc.Room != nil {
+		pc.Room.lastActive = time.Now()
+	}
+}
+
+// Internal version (no lock), closes the room and its sessions. Returns the
+// connections still in it, kick them once a.mu is unlocked.
+func (a *API) removeRoom(room *Room) []*PlayerConnection {
+	delete(a.Rooms, room.ID)
+	room.closeJournal()
+	if a.DefaultRoom == room.ID {
+		a.DefaultRoom = ""
+	}
+	for token, s := range a.sessions {
+		if s.room == room.ID {
+			delete(a.sessions, token)
+		}
+	}
+	conns := []*PlayerConnection{}
+	for _, pc := range a.Connections {
+		if pc.Room == room {
+			conns = append(conns, pc)
+		}
+	}
+	log.Printf("game %s (%s) closed", room.ID, room.Title)
+	return conns
+}
+
+// Tells the connections why and closes them, call it without a.mu so a slow
+// connection does not hold up the server
+func (a *API) kick(conns []*PlayerConnection, reason string) {
+	for _, pc := range conns {
+		a.sendError(pc, reason)
+		pc.conn.Close()
+	}
+}
+
+// Periodically closes finished and abandoned games
+func (a *API) janitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		a.mu.Lock()
+		rooms := make([]*Room, 0, len(a.Rooms))
+		for _, room := range a.Rooms {
+			rooms = append(rooms, room)
+		}
+		a.mu.Unlock()
+
+		for _, room := range rooms {
+			finished := room.Game.Phase() == model.PhaseFinished
+
+			a.mu.Lock()
+			idle := time.Since(room.lastActive)
+			connected := false
+			for _, pc := range a.Connections {
+				if pc.Room == room {
+					connected = true
+					break
+				}
+			}
+			// the default room stays open until its game is over
+			abandoned := !connected && room.ID != a.DefaultRoom && idle > abandonedRoomTimeout
+			var closed []*PlayerConnection
+			if (finished && idle > finishedRoomTimeout) || abandoned {
+				closed = a.removeRoom(room)
+			}
+			a.mu.Unlock()
+			a.kick(closed, "the game was closed")
+		}
+	}
+}
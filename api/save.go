@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/umarbektokyo/matetra-engine/bot"
+	"github.com/umarbektokyo/matetra-engine/cards"
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Bumped whenever the save file layout changes, see migrate
+const saveVersion = 2
+
+type savedRoom struct {
+	ID      string
+	Title   string
+	Created time.Time
+	Game    *engine.Snapshot
+	Bots    []savedBot `json:",omitempty"`
+}
+
+// Bots start over with a fresh strategy of the same kind
+type savedBot struct {
+	Player   int
+	Strategy string
+}
+
+type savedSession struct {
+	Room   string
+	Player int
+}
+
+// Save file of the whole server, sessions are kept so clients can RESUME after a restart
+type savedServer struct {
+	Version     int
+	Saved       time.Time
+	Defaults    model.Settings
+	DefaultRoom string
+	Rooms       []savedRoom
+	Sessions    map[string]savedSession
+}
+
+// Writes every game to path, the file is replaced in one step so a crash
+// while saving never leaves half a file behind
+func (a *API) Save(path string) error {
+	a.mu.Lock()
+	save := savedServer{
+		Version:     saveVersion,
+		Saved:       time.Now(),
+		Defaults:    a.Defaults,
+		DefaultRoom: a.DefaultRoom,
+		Sessions:    make(map[string]savedSession, len(a.sessions)),
+	}
+	rooms := make([]*Room, 0, len(a.Rooms))
+	bots := make(map[string][]savedBot, len(a.Rooms))
+	for _, room := range a.Rooms {
+		rooms = append(rooms, room)
+		for _, b := range room.bots {
+			bots[room.ID] = append(bots[room.ID], savedBot{Player: b.Player, Strategy: b.Kind})
+		}
+	}
+	for token, s := range a.sessions {
+		save.Sessions[token] = savedSession{Room: s.room, Player: s.player}
+	}
+	a.mu.Unlock()
+
+	for _, room := range rooms {
+		snapshot, err := room.Game.Snapshot()
+		if err != nil {
+			return fmt.Errorf("game %s: %v", room.ID, err)
+		}
+		save.Rooms = append(save.Rooms, savedRoom{
+			ID:      room.ID,
+			Title:   room.Title,
+			Created: room.Created,
+			Game:    snapshot,
+			Bots:    bots[room.ID],
+		})
+	}
+
+	data, err := json.Marshal(save)
+	if err != nil {
+		return fmt.Errorf("failed to encode the games: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Brings back a server saved with Save, the journals of its games carry on
+// in the files next to journalPath, see SetJournal ("" writes none)
+func Restore(path, journalPath string) (*API, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var save savedServer
+	if err := json.Unmarshal(data, &save); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	if save.Version < 1 || save.Version > saveVersion {
+		return nil, fmt.Errorf("%s has version %d, expected 1..%d", path, save.Version, saveVersion)
+	}
+	migrate(&save)
+
+	a := New(save.Defaults)
+	a.DefaultRoom = save.DefaultRoom
+	a.journalPath = journalPath
+	now := time.Now()
+	for _, saved := range save.Rooms {
+		game, err := engine.Restore(saved.Game)
+		if err != nil {
+			return nil, fmt.Errorf("game %s: %v", saved.ID, err)
+		}
+		room := &Room{
+			ID:         saved.ID,
+			Title:      saved.Title,
+			Game:       game,
+			Created:    saved.Created,
+			lastActive: now,
+		}
+		for _, b := range saved.Bots {
+			strategy, err := bot.NewStrategy(b.Strategy, now.UnixNano())
+			if err != nil {
+				return nil, fmt.Errorf("game %s: %v", saved.ID, err)
+			}
+			room.bots = append(room.bots, &bot.Bot{Player: b.Player, Kind: b.Strategy, Strategy: strategy})
+		}
+		if err := a.reopenJournal(room); err != nil {
+			return nil, fmt.Errorf("game %s: failed to reopen the journal: %v", saved.ID, err)
+		}
+		a.Rooms[saved.ID] = room
+	}
+	for token, s := range save.Sessions {
+		if a.Rooms[s.Room] != nil {
+			a.sessions[token] = session{room: s.Room, player: s.Player}
+		}
+	}
+
+	log.Printf("restored %d games saved at %s", len(a.Rooms), save.Saved.Format(time.RFC3339))
+	return a, nil
+}
+
+// Brings an older save up to saveVersion, one version at a time
+func migrate(save *savedServer) {
+	if save.Version == 1 {
+		// cards had no IDs, they get the ones a new game would give them
+		for _, room := range save.Rooms {
+			if room.Game != nil && room.Game.State != nil {
+				cards.AssignIDs(room.Game.State.Cards)
+			}
+		}
+		save.Version = 2
+	}
+}
+
+// Saves to path every interval
+func (a *API) Autosave(path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := a.Save(path); err != nil {
+			log.Printf("autosave failed: %v", err)
+		}
+	}
+}
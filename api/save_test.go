@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+func TestMigrateGivesCardsIDs(t *testing.T) {
+	save := savedServer{
+		Version: 1,
+		Rooms: []savedRoom{{
+			ID: "room",
+			Game: &engine.Snapshot{State: &model.GameState{Cards: []model.Card{
+				{Method: "ADD"}, {Method: "ADD"}, {Method: "DICE"},
+			}}},
+		}},
+	}
+	migrate(&save)
+
+	if save.Version != saveVersion {
+		t.Errorf("version %d, want %d", save.Version, saveVersion)
+	}
+	var ids []string
+	for _, card := range save.Rooms[0].Game.State.Cards {
+		ids = append(ids, card.ID)
+	}
+	if want := []string{"ADD-1", "ADD-2", "DICE-1"}; len(ids) != 3 || ids[0] != want[0] || ids[1] != want[1] || ids[2] != want[2] {
+		t.Errorf("IDs %v, want %v", ids, want)
+	}
+}
+
+func TestPlayInputJSON(t *testing.T) {
+	var payload CardPlayPayload
+	if err := json.Unmarshal([]byte(`{"card_index":3,"inputs":[1,"ADD-3"],"permanent":true}`), &payload); err != nil {
+		t.Fatal(err)
+	}
+	want := []PlayInput{{Value: 1}, {CardID: "ADD-3"}}
+	if len(payload.Inputs) != 2 || payload.Inputs[0] != want[0] || payload.Inputs[1] != want[1] {
+		t.Errorf("inputs %+v, want %+v", payload.Inputs, want)
+	}
+
+	data, err := json.Marshal(payload.Inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[1,"ADD-3"]` {
+		t.Errorf("encoded %s", data)
+	}
+}
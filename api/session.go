@@ -0,0 +1,132 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+
+	"github.com/umarbektokyo/matetra-engine/engine"
+)
+
+type ResumePayload struct {
+	Token string `json:"token"`
+}
+
+// Seat a session token belongs to
+type session struct {
+	room   string
+	player int
+}
+
+// Random session token handed out on join
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("failed to generate session token: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Binds the connection to the seat and issues a fresh session token, older
+// tokens and other connections of the seat stop working
+func (a *API) bindPlayer(pc *PlayerConnection, room *Room, playerID int) string {
+	a.mu.Lock()
+
+	seat := session{room: room.ID, player: playerID}
+	for token, s := range a.sessions {
+		if s == seat {
+			delete(a.sessions, token)
+		}
+	}
+	token := newToken()
+	a.sessions[token] = seat
+
+	replaced := a.takeSeat(pc, room, playerID)
+	a.mu.Unlock()
+
+	a.kick(replaced, "signed in from another connection")
+	return token
+}
+
+// Internal version (no lock), the seat can only be used from one connection at
+// a time. Returns the other connections of the seat, kick them once a.mu is
+// unlocked.
+func (a *API) takeSeat(pc *PlayerConnection, room *Room, playerID int) []*PlayerConnection {
+	replaced := []*PlayerConnection{}
+	for _, other := range a.Connections {
+		if other != pc && other.Room == room && other.PlayerID == playerID {
+			replaced = append(replaced, other)
+		}
+	}
+	pc.Room = room
+	pc.PlayerID = playerID
+	a.touch(pc)
+	return replaced
+}
+
+// Reclaims a seat with the token from an earlier connection
+func (a *API) handleResume(pc *PlayerConnection, payload interface{}) {
+	var resumePayload ResumePayload
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		a.sendError(pc, "error parsing resume payload")
+		return
+	}
+	if err := json.Unmarshal(payloadBytes, &resumePayload); err != nil {
+		a.sendError(pc, "invalid resume payload format")
+		return
+	}
+
+	a.mu.Lock()
+	seat, ok := a.sessions[resumePayload.Token]
+	room := a.Rooms[seat.room]
+	var replaced []*PlayerConnection
+	if ok && room != nil {
+		replaced = a.takeSeat(pc, room, seat.player)
+	}
+	a.mu.Unlock()
+	a.kick(replaced, "signed in from another connection")
+
+	if !ok || room == nil {
+		a.sendError(pc, "unknown or expired session")
+		return
+	}
+
+	playerID := seat.player
+	state := room.Game.CopyState()
+	a.sendResponse(pc, "RESUMED", PlayerAddedPayload{
+		GameID:   room.ID,
+		Name:     state.Players[playerID].Name,
+		PlayerID: playerID,
+		Rejoined: true,
+		Token:    resumePayload.Token,
+	})
+	a.sendResponse(pc, "STATE_UPDATE", engine.ViewFor(state, playerID))
+}
+
+// Writes a message, a connection that cannot be written to is closed so its
+// reader stops and removes it
+func (pc *PlayerConnection) writeJSON(v interface{}) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	err := pc.conn.WriteJSON(v)
+	if err != nil {
+		pc.conn.Close()
+	}
+	return err
+}
+
+// Returns the seat the connection is bound to, -1 if none
+func (a *API) playerOf(pc *PlayerConnection) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return pc.PlayerID
+}
+
+func (a *API) removeConnection(connID int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.Connections, connID)
+}
@@ -0,0 +1,367 @@
+// Arbitrary-precision versions of the math functions used by the cards. Every
+// function works at the requested precision (in bits) plus guard bits and
+// rounds the result to prec.
+package bigmath
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Extra bits carried through intermediate steps
+const guard = 64
+
+// Largest |x| Exp can take before the result overflows big.Float
+const maxExpArg = 1.4e9
+
+// Sin, Cos and Tan take |x| < 2^maxTrigExp, reducing x costs exponent(x)
+// extra bits of pi
+const maxTrigExp = 4096
+
+func newFloat(prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec)
+}
+
+// Rounds x to prec bits
+func round(x *big.Float, prec uint) *big.Float {
+	return newFloat(prec).Set(x)
+}
+
+// Binary exponent of x, 0 for zero
+func exponent(x *big.Float) int {
+	if x.Sign() == 0 {
+		return 0
+	}
+	return x.MantExp(nil)
+}
+
+// Sum of 1/((2k+1) n^(2k+1)) with alternating signs
+func atanInv(n int64, prec uint) *big.Float {
+	sum := newFloat(prec)
+	nf := newFloat(prec).SetInt64(n)
+	n2 := newFloat(prec).Mul(nf, nf)
+	power := newFloat(prec).Quo(newFloat(prec).SetInt64(1), nf) // 1/n^(2k+1)
+
+	for k := int64(0); ; k++ {
+		term := newFloat(prec).Quo(power, newFloat(prec).SetInt64(2*k+1))
+		if term.Sign() == 0 || exponent(term) < -int(prec) {
+			break
+		}
+		if k%2 == 0 {
+			sum.Add(sum, term)
+		} else {
+			sum.Sub(sum, term)
+		}
+		power.Quo(power, n2)
+	}
+	return sum
+}
+
+// Machin's formula: pi = 16 atan(1/5) - 4 atan(1/239)
+func Pi(prec uint) *big.Float {
+	p := prec + guard
+	a := atanInv(5, p)
+	b := atanInv(239, p)
+	pi := newFloat(p).Mul(a, newFloat(p).SetInt64(16))
+	pi.Sub(pi, newFloat(p).Mul(b, newFloat(p).SetInt64(4)))
+	return round(pi, prec)
+}
+
+func E(prec uint) *big.Float {
+	e, _ := Exp(newFloat(prec).SetInt64(1), prec)
+	return e
+}
+
+// Golden ratio (1 + sqrt 5) / 2
+func Phi(prec uint) *big.Float {
+	p := prec + guard
+	phi := newFloat(p).Sqrt(newFloat(p).SetInt64(5))
+	phi.Add(phi, newFloat(p).SetInt64(1))
+	phi.Quo(phi, newFloat(p).SetInt64(2))
+	return round(phi, prec)
+}
+
+// e^x
+func Exp(x *big.Float, prec uint) (*big.Float, error) {
+	if x.IsInf() {
+		return nil, fmt.Errorf("exp of infinity")
+	}
+	if x.Sign() == 0 {
+		return newFloat(prec).SetInt64(1), nil
+	}
+
+	f, _ := x.Float64()
+	if f > maxExpArg {
+		return nil, fmt.Errorf("exp(%s) is too large", x.Text('g', 10))
+	}
+	if f < -maxExpArg {
+		return newFloat(prec), nil
+	}
+
+	// e^x = (e^(x/2^k))^(2^k) with |x/2^k| < 2^-8, every squaring costs about a bit
+	k := exponent(x) + 8
+	if k < 0 {
+		k = 0
+	}
+	p := prec + guard + uint(k)
+
+	r := newFloat(p).SetMantExp(x, -k)
+
+	// Taylor series
+	sum := newFloat(p).SetInt64(1)
+	term := newFloat(p).SetInt64(1)
+	for n := int64(1); ; n++ {
+		term.Mul(term, r)
+		term.Quo(term, newFloat(p).SetInt64(n))
+		if term.Sign() == 0 || exponent(term) < -int(p) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+
+	for i := 0; i < k; i++ {
+		sum.Mul(sum, sum)
+	}
+	return round(sum, prec), nil
+}
+
+// 2 atanh(z) = 2 (z + z^3/3 + z^5/5 + ...), converges for |z| < 1
+func atanh2(z *big.Float, prec uint) *big.Float {
+	sum := newFloat(prec)
+	z2 := newFloat(prec).Mul(z, z)
+	power := newFloat(prec).Set(z)
+	for k := int64(0); ; k++ {
+		term := newFloat(prec).Quo(power, newFloat(prec).SetInt64(2*k+1))
+		if term.Sign() == 0 || exponent(term)-exponent(sum) < -int(prec) {
+			break
+		}
+		sum.Add(sum, term)
+		power.Mul(power, z2)
+	}
+	return sum.Mul(sum, newFloat(prec).SetInt64(2))
+}
+
+func ln2(prec uint) *big.Float {
+	third := newFloat(prec).Quo(newFloat(prec).SetInt64(1), newFloat(prec).SetInt64(3))
+	return atanh2(third, prec)
+}
+
+// Natural logarithm, x must be positive
+func Log(x *big.Float, prec uint) (*big.Float, error) {
+	if x.Sign() <= 0 {
+		return nil, fmt.Errorf("logarithm of a non-positive number")
+	}
+	if x.IsInf() {
+		return nil, fmt.Errorf("logarithm of infinity")
+	}
+	// the reduction below would leave ln 0.5 + ln 2 with a rounding error
+	if x.Cmp(big.NewFloat(1)) == 0 {
+		return newFloat(prec), nil
+	}
+
+	p := prec + guard
+
+	// x = m * 2^e with m in [0.5, 1), ln x = ln m + e ln 2
+	m := newFloat(p)
+	e := x.MantExp(m)
+
+	// ln m = 2 atanh((m-1)/(m+1))
+	one := newFloat(p).SetInt64(1)
+	z := newFloat(p).Quo(newFloat(p).Sub(m, one), newFloat(p).Add(m, one))
+	result := atanh2(z, p)
+
+	if e != 0 {
+		l2 := ln2(p + 32)
+		result.Add(result, newFloat(p).Mul(l2, newFloat(p).SetInt64(int64(e))))
+	}
+	return round(result, prec), nil
+}
+
+// Logarithm in the given base, base must be positive and not 1
+func LogBase(x, base *big.Float, prec uint) (*big.Float, error) {
+	if base.Cmp(big.NewFloat(1)) == 0 {
+		return nil, fmt.Errorf("logarithm base cannot be 1")
+	}
+	num, err := Log(x, prec+guard)
+	if err != nil {
+		return nil, err
+	}
+	den, err := Log(base, prec+guard)
+	if err != nil {
+		return nil, err
+	}
+	return newFloat(prec).Quo(num, den), nil
+}
+
+func Log10(x *big.Float, prec uint) (*big.Float, error) {
+	return LogBase(x, newFloat(prec).SetInt64(10), prec)
+}
+
+// Reduces x into [-pi, pi] and returns sin and cos with Taylor series
+func sinCos(x *big.Float, prec uint) (*big.Float, *big.Float, error) {
+	if x.IsInf() {
+		return nil, nil, fmt.Errorf("trigonometry of infinity")
+	}
+
+	// subtracting multiples of 2 pi cancels about exponent(x) bits
+	extra := exponent(x)
+	if extra > maxTrigExp {
+		return nil, nil, fmt.Errorf("trigonometry of %s, the argument is too large", x.Text('g', 10))
+	}
+	if extra < 0 {
+		extra = 0
+	}
+	p := prec + guard + uint(extra)
+
+	twoPi := Pi(p)
+	twoPi.Mul(twoPi, newFloat(p).SetInt64(2))
+
+	r := newFloat(p).Set(x)
+	turns := newFloat(p).Quo(r, twoPi)
+	k, _ := turns.Int(nil)
+	// round to the nearest turn
+	frac := newFloat(p).Sub(turns, newFloat(p).SetInt(k))
+	half := big.NewFloat(0.5)
+	if frac.Cmp(half) > 0 {
+		k.Add(k, big.NewInt(1))
+	} else if frac.Cmp(newFloat(p).Neg(half)) < 0 {
+		k.Sub(k, big.NewInt(1))
+	}
+	r.Sub(r, newFloat(p).Mul(twoPi, newFloat(p).SetInt(k)))
+
+	r2 := newFloat(p).Mul(r, r)
+
+	// sin r = r - r^3/3! + ..., cos r = 1 - r^2/2! + ...
+	sin := newFloat(p).Set(r)
+	cos := newFloat(p).SetInt64(1)
+	sinTerm := newFloat(p).Set(r)
+	cosTerm := newFloat(p).SetInt64(1)
+	for n := int64(1); ; n++ {
+		sinTerm.Mul(sinTerm, r2)
+		sinTerm.Quo(sinTerm, newFloat(p).SetInt64((2*n)*(2*n+1)))
+		sinTerm.Neg(sinTerm)
+		cosTerm.Mul(cosTerm, r2)
+		cosTerm.Quo(cosTerm, newFloat(p).SetInt64((2*n-1)*(2*n)))
+		cosTerm.Neg(cosTerm)
+
+		sin.Add(sin, sinTerm)
+		cos.Add(cos, cosTerm)
+
+		small := func(t *big.Float) bool { return t.Sign() == 0 || exponent(t) < -int(p) }
+		if small(sinTerm) && small(cosTerm) {
+			break
+		}
+	}
+
+	return round(sin, prec), round(cos, prec), nil
+}
+
+func Sin(x *big.Float, prec uint) (*big.Float, error) {
+	sin, _, err := sinCos(x, prec)
+	return sin, err
+}
+
+func Cos(x *big.Float, prec uint) (*big.Float, error) {
+	_, cos, err := sinCos(x, prec)
+	return cos, err
+}
+
+func Tan(x *big.Float, prec uint) (*big.Float, error) {
+	sin, cos, err := sinCos(x, prec+guard)
+	if err != nil {
+		return nil, err
+	}
+	if cos.Sign() == 0 {
+		return nil, fmt.Errorf("tangent is undefined at %s", x.Text('g', 10))
+	}
+	return newFloat(prec).Quo(sin, cos), nil
+}
+
+// x^n by repeated squaring
+func PowInt(x *big.Float, n int64, prec uint) (*big.Float, error) {
+	if n < 0 {
+		if x.Sign() == 0 {
+			return nil, fmt.Errorf("cannot divide by zero")
+		}
+		inv := newFloat(prec+guard).Quo(big.NewFloat(1), x)
+		return PowInt(inv, -n, prec)
+	}
+
+	p := prec + guard
+	result := newFloat(p).SetInt64(1)
+	base := newFloat(p).Set(x)
+	for n > 0 {
+		if n&1 == 1 {
+			result.Mul(result, base)
+		}
+		base.Mul(base, base)
+		n >>= 1
+	}
+	if result.IsInf() {
+		return nil, fmt.Errorf("power is too large")
+	}
+	return round(result, prec), nil
+}
+
+// x^y, x must be positive unless y is an integer
+func Pow(x, y *big.Float, prec uint) (*big.Float, error) {
+	if y.IsInt() {
+		if n, acc := y.Int64(); acc == big.Exact {
+			return PowInt(x, n, prec)
+		}
+	}
+
+	switch x.Sign() {
+	case -1:
+		return nil, fmt.Errorf("negative base needs an integer exponent")
+	case 0:
+		if y.Sign() > 0 {
+			return newFloat(prec), nil
+		}
+		return nil, fmt.Errorf("cannot divide by zero")
+	}
+
+	// e^(y ln x), the exponent's magnitude costs precision in ln x
+	extra := exponent(y)
+	if lnx, _ := x.Float64(); lnx != 0 {
+		extra += int(math.Log2(math.Abs(math.Log(lnx))+1)) + 1
+	}
+	if extra < 0 {
+		extra = 0
+	}
+	p := prec + guard + uint(extra)
+
+	lnx, err := Log(x, p)
+	if err != nil {
+		return nil, err
+	}
+	return Exp(newFloat(p).Mul(lnx, y), prec)
+}
+
+// n-th root, negative x only has odd roots
+func Root(x *big.Float, n int64, prec uint) (*big.Float, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("root degree must be positive")
+	}
+	switch x.Sign() {
+	case 0:
+		return newFloat(prec), nil
+	case -1:
+		if n%2 == 0 {
+			return nil, fmt.Errorf("even root of a negative number")
+		}
+		r, err := Root(newFloat(prec+guard).Neg(x), n, prec)
+		if err != nil {
+			return nil, err
+		}
+		return r.Neg(r), nil
+	}
+
+	p := prec + guard
+	lnx, err := Log(x, p)
+	if err != nil {
+		return nil, err
+	}
+	return Exp(newFloat(p).Quo(lnx, newFloat(p).SetInt64(n)), prec)
+}
@@ -0,0 +1,198 @@
+package bigmath
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+const prec = 256
+
+// Checks got against want to float64 precision
+func near(t *testing.T, name string, got *big.Float, want float64) {
+	t.Helper()
+	f, _ := got.Float64()
+	if want == 0 {
+		if math.Abs(f) > 1e-15 {
+			t.Errorf("%s = %g, want 0", name, f)
+		}
+		return
+	}
+	if math.Abs(f-want)/math.Abs(want) > 1e-14 {
+		t.Errorf("%s = %.17g, want %.17g", name, f, want)
+	}
+}
+
+func float(x float64) *big.Float {
+	return new(big.Float).SetPrec(prec).SetFloat64(x)
+}
+
+func TestExp(t *testing.T) {
+	for _, x := range []float64{0, 1, -1, 0.5, 2.5, 10, -20, 100} {
+		got, err := Exp(float(x), prec)
+		if err != nil {
+			t.Fatalf("Exp(%g): %v", x, err)
+		}
+		near(t, "Exp", got, math.Exp(x))
+	}
+	if _, err := Exp(float(2e9), prec); err == nil {
+		t.Errorf("Exp(2e9) should be too large")
+	}
+}
+
+func TestLog(t *testing.T) {
+	for _, x := range []float64{1, 2, 0.5, 10, 1e-9, 3.75, 1e100} {
+		got, err := Log(float(x), prec)
+		if err != nil {
+			t.Fatalf("Log(%g): %v", x, err)
+		}
+		near(t, "Log", got, math.Log(x))
+
+		got, err = Log10(float(x), prec)
+		if err != nil {
+			t.Fatalf("Log10(%g): %v", x, err)
+		}
+		near(t, "Log10", got, math.Log10(x))
+	}
+	for _, x := range []float64{0, -1} {
+		if _, err := Log(float(x), prec); err == nil {
+			t.Errorf("Log(%g) should fail", x)
+		}
+	}
+}
+
+func TestLogOfOneIsZero(t *testing.T) {
+	got, _ := Log(float(1), prec)
+	if got.Sign() != 0 {
+		t.Errorf("Log(1) = %s, want exactly 0", got.Text('g', 10))
+	}
+	got, _ = Log10(float(1), prec)
+	if got.Sign() != 0 {
+		t.Errorf("Log10(1) = %s, want exactly 0", got.Text('g', 10))
+	}
+}
+
+func TestLogBase(t *testing.T) {
+	tests := []struct{ x, base, want float64 }{
+		{8, 2, 3},
+		{1, 5, 0},
+		{81, 3, 4},
+		{0.25, 2, -2},
+		{10, 0.5, math.Log(10) / math.Log(0.5)},
+	}
+	for _, tt := range tests {
+		got, err := LogBase(float(tt.x), float(tt.base), prec)
+		if err != nil {
+			t.Fatalf("LogBase(%g, %g): %v", tt.x, tt.base, err)
+		}
+		near(t, "LogBase", got, tt.want)
+	}
+
+	for _, tt := range []struct{ x, base float64 }{{5, 1}, {1, 1}, {5, 0}, {5, -2}, {0, 2}} {
+		if _, err := LogBase(float(tt.x), float(tt.base), prec); err == nil {
+			t.Errorf("LogBase(%g, %g) should fail", tt.x, tt.base)
+		}
+	}
+}
+
+func TestTrig(t *testing.T) {
+	for _, x := range []float64{0, 1, -1, 0.5, 3, -7.25, 100, 1e6} {
+		sin, err := Sin(float(x), prec)
+		if err != nil {
+			t.Fatalf("Sin(%g): %v", x, err)
+		}
+		near(t, "Sin", sin, math.Sin(x))
+
+		cos, err := Cos(float(x), prec)
+		if err != nil {
+			t.Fatalf("Cos(%g): %v", x, err)
+		}
+		near(t, "Cos", cos, math.Cos(x))
+
+		tan, err := Tan(float(x), prec)
+		if err != nil {
+			t.Fatalf("Tan(%g): %v", x, err)
+		}
+		near(t, "Tan", tan, math.Tan(x))
+	}
+}
+
+func TestTrigOfHugeArgument(t *testing.T) {
+	largest := new(big.Float).SetMantExp(big.NewFloat(0.75), maxTrigExp)
+	if _, err := Sin(largest, prec); err != nil {
+		t.Errorf("Sin(2^%d) should still work: %v", maxTrigExp-1, err)
+	}
+
+	huge := new(big.Float).SetMantExp(big.NewFloat(1), 1<<20)
+	for name, f := range map[string]func(*big.Float, uint) (*big.Float, error){"Sin": Sin, "Cos": Cos, "Tan": Tan} {
+		if _, err := f(huge, prec); err == nil {
+			t.Errorf("%s(2^%d) should fail", name, 1<<20)
+		}
+	}
+}
+
+func TestRoot(t *testing.T) {
+	tests := []struct {
+		x    float64
+		n    int64
+		want float64
+	}{
+		{27, 3, 3},
+		{-27, 3, -3},
+		{2, 2, math.Sqrt2},
+		{0, 5, 0},
+		{1, 7, 1},
+	}
+	for _, tt := range tests {
+		got, err := Root(float(tt.x), tt.n, prec)
+		if err != nil {
+			t.Fatalf("Root(%g, %d): %v", tt.x, tt.n, err)
+		}
+		near(t, "Root", got, tt.want)
+	}
+
+	if _, err := Root(float(-4), 2, prec); err == nil {
+		t.Errorf("even root of a negative number should fail")
+	}
+	if _, err := Root(float(4), 0, prec); err == nil {
+		t.Errorf("root of degree 0 should fail")
+	}
+}
+
+func TestPow(t *testing.T) {
+	tests := []struct{ x, y, want float64 }{
+		{2, 10, 1024},
+		{-2, 3, -8},
+		{2, -2, 0.25},
+		{2, 0.5, math.Sqrt2},
+		{10, -1.5, math.Pow(10, -1.5)},
+		{0, 3, 0},
+		{1, 1000.5, 1},
+		{5, 0, 1},
+	}
+	for _, tt := range tests {
+		got, err := Pow(float(tt.x), float(tt.y), prec)
+		if err != nil {
+			t.Fatalf("Pow(%g, %g): %v", tt.x, tt.y, err)
+		}
+		near(t, "Pow", got, tt.want)
+	}
+
+	for _, tt := range []struct{ x, y float64 }{{-2, 0.5}, {0, -1}, {0, -0.5}} {
+		if _, err := Pow(float(tt.x), float(tt.y), prec); err == nil {
+			t.Errorf("Pow(%g, %g) should fail", tt.x, tt.y)
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	near(t, "Pi", Pi(prec), math.Pi)
+	near(t, "E", E(prec), math.E)
+	near(t, "Phi", Phi(prec), math.Phi)
+
+	// the first 50 digits, well past float64
+	const pi = "3.1415926535897932384626433832795028841971693993751"
+	if got := Pi(prec).Text('f', 49); got != pi {
+		t.Errorf("Pi = %s, want %s", got, pi)
+	}
+}
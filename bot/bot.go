@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Steps a bot takes, reported to the caller as they happen
+const (
+	ActionReady = "READY"
+	ActionRoll  = "ROLL"
+	ActionPlay  = "PLAY"
+	ActionEnd   = "END"
+)
+
+// What the bot just did and the state it left behind
+type Action struct {
+	Type  string
+	Play  Play // the card queued by ActionPlay
+	State *model.GameState
+}
+
+// A seat played by the computer
+type Bot struct {
+	Player   int
+	Kind     string // strategy name, see NewStrategy
+	Strategy Strategy
+}
+
+// Seats a new bot in the game, only possible in the lobby
+func Join(game *engine.Game, name, kind string, seed int64) (*Bot, error) {
+	strategy, err := NewStrategy(kind, seed)
+	if err != nil {
+		return nil, err
+	}
+	if game.Phase() != model.PhaseLobby {
+		return nil, fmt.Errorf("bots can only join in the lobby")
+	}
+
+	// nobody can log into a bot seat, its password is never handed out
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	player, _, err := game.AddPlayer(name, hex.EncodeToString(secret))
+	if err != nil {
+		return nil, err
+	}
+	return &Bot{Player: player, Kind: kind, Strategy: strategy}, nil
+}
+
+// Whether the bot has something to do in this state. On other players'
+// turns it waits for the current player to finish, so the current player can
+// still roll the dice before any card is queued.
+func (b *Bot) Due(gs *model.GameState) bool {
+	switch gs.Phase {
+	case model.PhaseLobby:
+		return !gs.Ready[b.Player]
+	case model.PhasePlaying:
+		if gs.Done[b.Player] {
+			return false
+		}
+		current := gs.Turn % len(gs.Players)
+		return current == b.Player || gs.Done[current]
+	default:
+		return false
+	}
+}
+
+// Readies up in the lobby, or plays the whole turn: rolls, queues cards
+// until the strategy is done and ends the turn. Every step goes to report.
+func (b *Bot) Act(game *engine.Game, report func(Action)) error {
+	gs := game.CopyState()
+	if !b.Due(gs) {
+		return nil
+	}
+
+	if gs.Phase == model.PhaseLobby {
+		state, err := game.SetReady(b.Player, true)
+		if err != nil {
+			return err
+		}
+		report(Action{Type: ActionReady, State: state})
+		return nil
+	}
+
+	if b.canRoll(gs) && b.Strategy.Roll(game, b.Player) {
+		state, err := game.ProcessDiceRoll(b.Player)
+		if err != nil {
+			return err
+		}
+		report(Action{Type: ActionRoll, State: state})
+	}
+
+	for {
+		// a bot only knows what its player can see
+		plays := LegalPlays(engine.ViewFor(game.CopyState(), b.Player), b.Player)
+		play, ok := b.Strategy.Choose(game, b.Player, plays)
+		if !ok {
+			break
+		}
+		state, err := game.ProcessMove(b.Player, play.Card, play.Inputs, true)
+		if err != nil {
+			// the engine has the last word, the turn ends with what is queued
+			break
+		}
+		report(Action{Type: ActionPlay, Play: play, State: state})
+	}
+
+	state, err := game.ProcessNextTurn(b.Player)
+	if err != nil {
+		return err
+	}
+	report(Action{Type: ActionEnd, State: state})
+	return nil
+}
+
+// Dice can only be rolled on the bot's own turn, before any card is queued
+// and while the row has an empty slot
+func (b *Bot) canRoll(gs *model.GameState) bool {
+	if gs.Turn%len(gs.Players) != b.Player || len(gs.Queue) > 0 {
+		return false
+	}
+	for _, num := range gs.Numbers[b.Player] {
+		if num.Mark == "n" {
+			return true
+		}
+	}
+	return false
+}
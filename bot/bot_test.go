@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// A started game with a bot of every kind, seeded
+func botGame(t *testing.T, seed int64, kinds ...string) (*engine.Game, []*Bot) {
+	t.Helper()
+	game := engine.NewSeeded("test", seed)
+	bots := make([]*Bot, len(kinds))
+	for seat, kind := range kinds {
+		b, err := Join(game, fmt.Sprintf("%s-%d", kind, seat), kind, seed+int64(seat))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bots[seat] = b
+	}
+	for _, b := range bots {
+		if err := b.Act(game, func(Action) {}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := game.StartGame(0); err != nil {
+		t.Fatal(err)
+	}
+	return game, bots
+}
+
+// Fills every row with small non-zero numbers
+func fillRows(game *engine.Game) {
+	for p := range game.State.Numbers {
+		for i := range game.State.Numbers[p] {
+			game.State.Numbers[p][i] = model.IntNumber(int64(2+p+i), false, model.DefaultPrec)
+		}
+	}
+}
+
+func TestBotsPlayAGameToTheEnd(t *testing.T) {
+	game, bots := botGame(t, 1, StrategyGreedy, StrategyRandom)
+	game.SetVictoryConditions(engine.HighestSum(20))
+
+	for steps := 0; !game.IsOver(); steps++ {
+		if steps > 1000 {
+			t.Fatalf("the game is still going on turn %d", game.CopyState().Turn)
+		}
+		state := game.CopyState()
+		var due *Bot
+		for _, b := range bots {
+			if b.Due(state) {
+				due = b
+				break
+			}
+		}
+		if due == nil {
+			t.Fatalf("no bot can move on turn %d", state.Turn)
+		}
+		if err := due.Act(game, func(Action) {}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state := game.CopyState()
+	if state.Turn != 20 || len(state.Winners) == 0 || len(state.Standings) != 2 {
+		t.Errorf("the game ended on turn %d with winners %v and standings %+v", state.Turn, state.Winners, state.Standings)
+	}
+}
+
+func TestGreedyPicksTheBestPreview(t *testing.T) {
+	game, _ := botGame(t, 2, StrategyGreedy, StrategyGreedy)
+	fillRows(game)
+
+	plays := LegalPlays(engine.ViewFor(game.CopyState(), 0), 0)
+	if len(plays) == 0 {
+		t.Fatal("no legal plays")
+	}
+	current, err := game.PreviewQueue(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best := Advantage(current, 0)
+	for _, play := range plays {
+		preview, err := game.ProcessMove(0, play.Card, play.Inputs, false)
+		if err == nil {
+			best = math.Max(best, Advantage(preview, 0))
+		}
+	}
+
+	play, ok := NewGreedy(Advantage).Choose(game, 0, plays)
+	if !ok {
+		t.Fatalf("greedy played nothing, the best preview scores %v", best)
+	}
+	preview, err := game.ProcessMove(0, play.Card, play.Inputs, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Advantage(preview, 0); got != best {
+		t.Errorf("greedy picked a play scoring %v, the best scores %v", got, best)
+	}
+}
+
+func TestLegalPlaysPassProcessMove(t *testing.T) {
+	game, _ := botGame(t, 3, StrategyRandom, StrategyRandom)
+	fillRows(game)
+
+	for player := range game.State.Players {
+		plays := LegalPlays(engine.ViewFor(game.CopyState(), player), player)
+		if len(plays) == 0 {
+			t.Errorf("player %d has no legal plays", player)
+		}
+		for _, play := range plays {
+			if _, err := game.ProcessMove(player, play.Card, play.Inputs, false); err != nil {
+				t.Errorf("%s with %v: %v", game.State.Cards[play.Card].ID, play.Inputs, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"math"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Scores a (previewed) state from the player's seat, higher is better
+type Evaluator func(gs *model.GameState, player int) float64
+
+// Own row sum against the best row sum of the other players
+func Advantage(gs *model.GameState, player int) float64 {
+	own := rowSum(gs, player)
+	best := math.Inf(-1)
+	for p := range gs.Players {
+		if p != player {
+			best = math.Max(best, rowSum(gs, p))
+		}
+	}
+	if math.IsInf(best, -1) {
+		return own
+	}
+	return own - best
+}
+
+// Bots compare thousands of states, so values are compared as float64s clamped
+// to ±maxValue instead of adding big.Floats whose exponents can be far apart
+const maxValue = 1e300
+
+func value(num model.Number) float64 {
+	f, _ := num.Value.Float64()
+	return math.Max(-maxValue, math.Min(maxValue, f))
+}
+
+// Like engine.RowSum, in float64
+func rowSum(gs *model.GameState, player int) float64 {
+	sum := 0.0
+	for _, num := range gs.Numbers[player] {
+		if num.Mark != "n" && num.Value != nil {
+			sum += value(num)
+		}
+	}
+	return sum
+}
+
+// Default evaluation: follows the game's victory conditions. Holding the
+// target wins outright, otherwise getting closer to it counts as much as
+// growing the row.
+func Evaluate(gs *model.GameState, player int) float64 {
+	if gs.Phase == model.PhaseFinished {
+		for _, winner := range gs.Winners {
+			if winner == player {
+				return math.Inf(1)
+			}
+		}
+		return math.Inf(-1)
+	}
+
+	score := Advantage(gs, player)
+	for _, cond := range gs.Settings.Victory {
+		if cond.Type != model.VictoryTarget {
+			continue
+		}
+		distance := targetDistance(gs, player, cond.Target)
+		if distance == 0 {
+			return math.Inf(1)
+		}
+		score -= distance
+	}
+	return score
+}
+
+// Distance of the player's closest number to the target, the target itself
+// when the row is empty
+func targetDistance(gs *model.GameState, player int, target int64) float64 {
+	goal := float64(target)
+	closest := math.Abs(goal)
+	for _, num := range gs.Numbers[player] {
+		if num.Mark == "n" || num.Value == nil {
+			continue
+		}
+		closest = math.Min(closest, math.Abs(value(num)-goal))
+	}
+	return closest
+}
@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"github.com/umarbektokyo/matetra-engine/model"
+	"github.com/umarbektokyo/matetra-engine/utils"
+)
+
+// A card from the hand together with the inputs to queue it with
+type Play struct {
+	Card   int
+	Inputs []int
+}
+
+// Every way the player can queue the cards in their hand right now. Inputs
+// the bots do not understand yet (X, Y, i) leave the card out.
+func LegalPlays(gs *model.GameState, player int) []Play {
+	queued := make(map[int]bool, len(gs.Queue))
+	for _, cardIndex := range gs.Queue {
+		queued[cardIndex] = true
+	}
+
+	plays := []Play{}
+	for i := range gs.Cards {
+		if gs.Cards[i].Owner != player || queued[i] {
+			continue
+		}
+
+		card := gs.Cards[i]
+		for _, inputs := range candidates(gs, player, card.InputsReq) {
+			card.Inputs = inputs
+			if utils.ValidateInputs(gs, &card) == nil {
+				plays = append(plays, Play{Card: i, Inputs: inputs})
+			}
+		}
+	}
+	return plays
+}
+
+// Enumerates the input combinations for InputsReq, left to right
+func candidates(gs *model.GameState, player int, req string) [][]int {
+	specs, err := model.InputSpecs(req)
+	if err != nil {
+		return nil
+	}
+	combos := [][]int{{}}
+	for _, spec := range specs {
+		next := [][]int{}
+		for _, combo := range combos {
+			for _, val := range choices(gs, player, spec, combo) {
+				next = append(next, append(append([]int(nil), combo...), val))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// Values a single input can take given the inputs before it
+func choices(gs *model.GameState, player int, spec model.InputSpec, before []int) []int {
+	switch spec.Kind {
+	case model.InputDie:
+		return span(*spec.Min, *spec.Max)
+	case model.InputPlayer:
+		switch spec.Players {
+		case model.PlayersOwner:
+			return []int{player}
+		case model.PlayersDefender:
+			if len(gs.Players) == 0 {
+				return nil
+			}
+			return []int{gs.Turn % len(gs.Players)}
+		}
+		return span(0, len(gs.Players)-1)
+	case model.InputNumber:
+		// the PLAYER input the number belongs to
+		dep := spec.DependsOn[0]
+		if dep < 0 || dep >= len(before) || before[dep] < 0 || before[dep] >= len(gs.Numbers) {
+			return nil
+		}
+		return span(0, len(gs.Numbers[before[dep]])-1)
+	case model.InputCard:
+		// cards the other players have queued
+		targets := []int{}
+		for _, cardIndex := range gs.Queue {
+			if gs.Cards[cardIndex].Owner != player {
+				targets = append(targets, cardIndex)
+			}
+		}
+		return targets
+	default:
+		return nil
+	}
+}
+
+// Every int in lo..hi
+func span(lo, hi int) []int {
+	vals := []int{}
+	for v := lo; v <= hi; v++ {
+		vals = append(vals, v)
+	}
+	return vals
+}
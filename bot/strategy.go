@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Built-in strategies
+const (
+	StrategyRandom = "random"
+	StrategyGreedy = "greedy"
+)
+
+// Decides what a bot does with its seat
+type Strategy interface {
+	// Whether to roll the dice before queueing cards on its own turn
+	Roll(game *engine.Game, player int) bool
+	// Picks the next card to queue among the legal plays, false ends the turn
+	Choose(game *engine.Game, player int, plays []Play) (Play, bool)
+}
+
+// Builds a strategy, the seed drives any randomness it has
+type StrategyFactory func(seed int64) Strategy
+
+var strategies = map[string]StrategyFactory{
+	StrategyRandom: func(seed int64) Strategy { return NewRandom(seed) },
+	StrategyGreedy: func(seed int64) Strategy { return NewGreedy(Evaluate) },
+}
+
+// Registers a custom strategy. The registry is not locked, call it from init
+// before any bot is created.
+func RegisterStrategy(kind string, factory StrategyFactory) {
+	strategies[kind] = factory
+}
+
+// Builds the strategy registered as kind
+func NewStrategy(kind string, seed int64) (Strategy, error) {
+	factory, ok := strategies[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown bot strategy %s, expected one of %v", kind, Strategies())
+	}
+	return factory(seed), nil
+}
+
+// Names of every registered strategy, sorted
+func Strategies() []string {
+	kinds := make([]string, 0, len(strategies))
+	for kind := range strategies {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Plays any legal card, about half of the time
+type Random struct {
+	rng *model.RNG
+}
+
+func NewRandom(seed int64) *Random {
+	return &Random{rng: model.NewRNG(seed)}
+}
+
+func (r *Random) Roll(game *engine.Game, player int) bool {
+	return r.rng.IntN(2) == 0
+}
+
+func (r *Random) Choose(game *engine.Game, player int, plays []Play) (Play, bool) {
+	if len(plays) == 0 || r.rng.IntN(2) == 0 {
+		return Play{}, false
+	}
+	return plays[r.rng.IntN(len(plays))], true
+}
+
+// Previews every legal play and queues the one scoring best, as long as it
+// beats leaving the queue as it is
+type Greedy struct {
+	Evaluate Evaluator
+}
+
+func NewGreedy(eval Evaluator) *Greedy {
+	return &Greedy{Evaluate: eval}
+}
+
+// A new number never hurts the row sum, so the dice are always worth it
+func (gr *Greedy) Roll(game *engine.Game, player int) bool {
+	return true
+}
+
+func (gr *Greedy) Choose(game *engine.Game, player int, plays []Play) (Play, bool) {
+	// what the queue already does without another card
+	current, err := game.PreviewQueue(player)
+	if err != nil {
+		return Play{}, false
+	}
+	best := gr.Evaluate(current, player)
+
+	var choice Play
+	found := false
+	for _, play := range plays {
+		preview, err := game.ProcessMove(player, play.Card, play.Inputs, false)
+		if err != nil {
+			continue
+		}
+		score := gr.Evaluate(preview, player)
+		if score > best {
+			best = score
+			choice = play
+			found = true
+		}
+	}
+	return choice, found
+}
@@ -0,0 +1,71 @@
+package cards
+
+import (
+	"github.com/umarbektokyo/matetra-engine/cards/constants"
+	"github.com/umarbektokyo/matetra-engine/cards/formula"
+	"github.com/umarbektokyo/matetra-engine/cards/functions"
+	"github.com/umarbektokyo/matetra-engine/cards/theorems"
+)
+
+// The methods behind the embedded deck
+func init() {
+	builtin := []Method{
+		// functions
+		{Name: "ADD", Type: "Function", Inputs: "AnUn", Apply: functions.ADD},
+		{Name: "SUBTRACT", Type: "Function", Inputs: "AnUn", Apply: functions.SUBTRACT},
+		{Name: "MULTIPLY", Type: "Function", Inputs: "AnUn", Apply: functions.MULTIPLY},
+		{Name: "DIVIDE", Type: "Function", Inputs: "AnUn", Apply: functions.DIVIDE},
+		{Name: "ABSOLUTEVALUE", Type: "Function", Inputs: "An", Apply: functions.ABSOLUTEVALUE},
+		{Name: "INVERSE", Type: "Function", Inputs: "An", Apply: functions.INVERSE},
+		{Name: "NEGATIVE", Type: "Function", Inputs: "An", Apply: functions.NEGATIVE},
+		{Name: "POSITIVE", Type: "Function", Inputs: "An", Apply: functions.POSITIVE},
+		{Name: "SQRT", Type: "Function", Inputs: "An", Apply: functions.SQRT},
+		{Name: "FACTORIAL", Type: "Constant", Inputs: "", Apply: constants.FACTORIAL},
+		{Name: "SQUARE", Type: "Function", Inputs: "An", Apply: functions.SQUARE},
+		{Name: "COSMOD", Type: "Function", Inputs: "An", Apply: functions.COSMOD},
+		{Name: "LOG10", Type: "Function", Inputs: "An", Apply: functions.LOG10},
+		{Name: "EXPONENTIAL", Type: "Function", Inputs: "An", Apply: functions.EXPONENTIAL},
+		{Name: "NATLOG", Type: "Function", Inputs: "An", Apply: functions.NATLOG},
+		{Name: "SINMOD", Type: "Function", Inputs: "An", Apply: functions.SINMOD},
+		{Name: "TANMOD", Type: "Function", Inputs: "An", Apply: functions.TANMOD},
+		{Name: "LOGORHYTHM", Type: "Function", Inputs: "An", Apply: functions.LOGORHYTHM},
+		{Name: "ROOTBASE", Type: "Function", Inputs: "An", Apply: functions.ROOTBASE},
+		{Name: "EXPONENTBASE", Type: "Function", Inputs: "An", Apply: functions.EXPONENTBASE},
+		{Name: "SIGMANOTATION", Type: "Function", Inputs: "A", Apply: functions.SIGMANOTATION},
+		{Name: "PRODUCTNOTATION", Type: "Function", Inputs: "A", Apply: functions.PRODUCTNOTATION},
+		{Name: "POLYNOMIAL2", Type: "Function", Inputs: "AnUnUn", Apply: functions.POLYNOMIAL2},
+		{Name: "POLYNOMIAL1", Type: "Function", Inputs: "AnUn", Apply: functions.POLYNOMIAL1},
+		// InputsReq follows the formula in the SVG column, see expectedInputs
+		{Name: FormulaMethod, Type: "Function", Inputs: "", Apply: formula.Apply},
+		// theorems
+		{Name: "ELEMENTIDENTITY", Type: "Theorem", Inputs: "An", Apply: theorems.ELEMENTIDENTITY},
+		{Name: "ELEMENTCLOSURE", Type: "Theorem", Inputs: "An", Apply: theorems.ELEMENTCLOSURE},
+		{Name: "ELEMENTDISTRIBUTIVE", Type: "Theorem", Inputs: "An", Apply: theorems.ELEMENTDISTRIBUTIVE},
+		{Name: "ELEMENTCOMMUTATIVE", Type: "Theorem", Inputs: "AnAn", Apply: theorems.ELEMENTCOMMUTATIVE},
+		{Name: "PYTHAGOREANTHEOREM", Type: "Theorem", Inputs: "AnUn", Apply: theorems.PYTHAGOREANTHEOREM},
+		{Name: "PASCALTRIANGLE", Type: "Theorem", Inputs: "An", Apply: theorems.PASCALTRIANGLE},
+		{Name: "FUNDAMENTALTHEOREMOFARITHMETIC", Type: "Theorem", Inputs: "An", Apply: theorems.FUNDAMENTALTHEOREMOFARITHMETIC},
+		{Name: "COUNTEREXAMPLE", Type: "Theorem", Inputs: "c", Apply: theorems.COUNTEREXAMPLE},
+		// constants
+		{Name: "CONSTE", Type: "Constant", Inputs: "", Apply: constants.CONSTE},
+		{Name: "CONSTN1", Type: "Constant", Inputs: "", Apply: constants.CONSTN1},
+		{Name: "CONST73", Type: "Constant", Inputs: "", Apply: constants.CONST73},
+		{Name: "CONSTGOOGLE", Type: "Constant", Inputs: "An", Apply: constants.CONSTGOOGLE},
+		{Name: "CONST42", Type: "Constant", Inputs: "", Apply: constants.CONST42},
+		{Name: "CONSTPHI", Type: "Constant", Inputs: "", Apply: constants.CONSTPHI},
+		{Name: "CONSTZERO", Type: "Constant", Inputs: "", Apply: constants.CONSTZERO},
+		{Name: "CONSTPI", Type: "Constant", Inputs: "", Apply: constants.CONSTPI},
+		{Name: "CONST7", Type: "Constant", Inputs: "", Apply: constants.CONST7},
+		{Name: "CONST26", Type: "Constant", Inputs: "", Apply: constants.CONST26},
+		{Name: "CONST6", Type: "Constant", Inputs: "", Apply: constants.CONST6},
+		{Name: "CONSTFIBONACCI", Type: "Constant", Inputs: "", Apply: constants.CONSTFIBONACCI},
+		{Name: "CONST69", Type: "Constant", Inputs: "", Apply: constants.CONST69},
+		{Name: "CONSTTAU", Type: "Constant", Inputs: "", Apply: constants.CONSTTAU},
+		{Name: "CONSTTENPOWER", Type: "Constant", Inputs: "", Apply: constants.CONSTTENPOWER},
+		{Name: "CONSTGRAHAM", Type: "Constant", Inputs: "", Apply: constants.CONSTGRAHAM},
+		{Name: "CONSTCUPID", Type: "Constant", Inputs: "", Apply: constants.CONSTCUPID},
+	}
+	for _, m := range builtin {
+		Register(m)
+	}
+}
@@ -1,15 +1,9 @@
 package cards
 
 import (
-	"bytes"
 	_ "embed"
-	"encoding/csv"
 	"fmt"
-	"strconv"
 
-	"github.com/umarbektokyo/matetra-engine/cards/constants"
-	"github.com/umarbektokyo/matetra-engine/cards/functions"
-	"github.com/umarbektokyo/matetra-engine/cards/theorems"
 	"github.com/umarbektokyo/matetra-engine/model"
 	"github.com/umarbektokyo/matetra-engine/utils"
 )
@@ -17,151 +11,141 @@ import (
 //go:embed cards.csv
 var CardsCSV []byte
 
-// Loads cards from embedded csv
-func LoadCards() ([]model.Card, error) {
-	// Read through the data and clean it
-	reader := csv.NewReader(bytes.NewReader(CardsCSV))
-	reader.TrimLeadingSpace = true
-	records, err := reader.ReadAll()
+// Loads cards from the deck, only from the given packs if any are given
+func LoadCards(packs ...string) ([]model.Card, error) {
+	defs, err := definitions()
 	if err != nil {
 		return nil, err
 	}
-	records = records[1:]
+
+	for _, pack := range packs {
+		if !HasPack(pack) {
+			return nil, fmt.Errorf("unknown pack %s", pack)
+		}
+	}
+	wanted := func(pack string) bool {
+		if len(packs) == 0 {
+			return true
+		}
+		for _, p := range packs {
+			if p == pack {
+				return true
+			}
+		}
+		return false
+	}
 
 	// Start recording the cards
 	var cards []model.Card
 
 	// Add each card (row)
-	for _, row := range records {
-		count := utils.Must(strconv.Atoi(row[6]))
+	for _, def := range defs {
+		if !wanted(def.Pack) {
+			continue
+		}
+
+		var source string
+		if def.Method == FormulaMethod {
+			source = def.SVG
+		}
 
 		// Add multiple copies if necessary
-		for i := 0; i < count; i++ {
+		for i := 0; i < def.Count; i++ {
 			card := model.Card{
-				Name:        row[0],
-				Description: row[2],
-				Type:        row[3],
-				Method:      row[4],
-				InputsReq:   row[5],
+				Name:        def.Name,
+				Description: def.Description,
+				Type:        cardType(def.Type, def.Method),
+				Method:      def.Method,
+				Precedence:  def.Precedence,
+				Pack:        def.Pack,
+				InputsReq:   def.InputsReq,
+				Formula:     source,
 				Owner:       -1,
 				Inputs:      []int{},
 			}
 			cards = append(cards, card)
 		}
 	}
+	AssignIDs(cards)
 	return cards, nil
 }
 
+// Numbers the copies of every method in deck order: ADD-1, ADD-2, ...
+func AssignIDs(deck []model.Card) {
+	copies := map[string]int{}
+	for i := range deck {
+		copies[deck[i].Method]++
+		deck[i].ID = fmt.Sprintf("%s-%d", deck[i].Method, copies[deck[i].Method])
+	}
+}
+
+// Checks every card of the deck against the registered methods, so a bad
+// deck fails when the server starts instead of when the card is played
+func Validate() error {
+	_, err := definitions()
+	return err
+}
+
+// Type column of a row, the method's own type when left empty
+func cardType(typ, method string) string {
+	if m, ok := Lookup(method); ok && typ == "" {
+		return m.Type
+	}
+	return typ
+}
+
+// Lists every pack in the deck with its cards, in the order they first appear
+func Packs() ([]model.Pack, error) {
+	defs, err := definitions()
+	if err != nil {
+		return nil, err
+	}
+
+	var packs []model.Pack
+	index := map[string]int{}
+	for _, def := range defs {
+		if def.Count == 0 {
+			continue
+		}
+		i, ok := index[def.Pack]
+		if !ok {
+			i = len(packs)
+			index[def.Pack] = i
+			packs = append(packs, model.Pack{Name: def.Pack})
+		}
+
+		packs[i].Size += def.Count
+		packs[i].Cards = append(packs[i].Cards, def.Name)
+	}
+	return packs, nil
+}
+
+// Checks if a pack exists in the deck
+func HasPack(name string) bool {
+	packs, err := Packs()
+	if err != nil {
+		return false
+	}
+	for _, pack := range packs {
+		if pack.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Applies the card at cardIndex with its registered method
 func CardFunction(vgs *model.GameState, cardIndex int) error {
-	var card *model.Card
+	card := &vgs.Cards[cardIndex]
 
-	card = &vgs.Cards[cardIndex]
+	m, ok := Lookup(card.Method)
+	if !ok {
+		return fmt.Errorf("unknown card method %s", card.Method)
+	}
 
 	if err := utils.ValidateInputs(vgs, card); err != nil {
 		return err
 	}
 
-	switch card.Method {
-	// functions
-	case "ADD":
-		return functions.ADD(vgs, card)
-	case "SUBTRACT":
-		return functions.SUBTRACT(vgs, card)
-	case "MULTIPLY":
-		return functions.MULTIPLY(vgs, card)
-	case "DIVIDE":
-		return functions.DIVIDE(vgs, card)
-	case "ABSOLUTEVALUE":
-		return functions.ABSOLUTEVALUE(vgs, card)
-	case "INVERSE":
-		return functions.INVERSE(vgs, card)
-	case "NEGATIVE":
-		return functions.NEGATIVE(vgs, card)
-	case "POSITIVE":
-		return functions.POSITIVE(vgs, card)
-	case "SQRT":
-		return functions.SQRT(vgs, card)
-	case "FACTORIAL":
-		return constants.FACTORIAL(vgs, card)
-	case "SQUARE":
-		return functions.SQUARE(vgs, card)
-	case "COSMOD":
-		return functions.COSMOD(vgs, card)
-	case "LOG10":
-		return functions.LOG10(vgs, card)
-	case "EXPONENTIAL":
-		return functions.EXPONENTIAL(vgs, card)
-	case "NATLOG":
-		return functions.NATLOG(vgs, card)
-	case "SINMOD":
-		return functions.SINMOD(vgs, card)
-	case "TANMOD":
-		return functions.TANMOD(vgs, card)
-	case "LOGORHYTHM":
-		return functions.LOGORHYTHM(vgs, card)
-	case "ROOTBASE":
-		return functions.ROOTBASE(vgs, card)
-	case "EXPONENTBASE":
-		return functions.EXPONENTBASE(vgs, card)
-	case "SIGMANOTATION":
-		return functions.SIGMANOTATION(vgs, card)
-	case "PRODUCTNOTATION":
-		return functions.PRODUCTNOTATION(vgs, card)
-	case "POLYNOMIAL2":
-		return functions.POLYNOMIAL2(vgs, card)
-	case "POLYNOMIAL1":
-		return functions.POLYNOMIAL1(vgs, card)
-	// theorems
-	case "ELEMENTIDENTITY":
-		return theorems.ELEMENTIDENTITY(vgs, card)
-	case "ELEMENTCLOSURE":
-		return theorems.ELEMENTCLOSURE(vgs, card)
-	case "ELEMENTDISTRIBUTIVE":
-		return theorems.ELEMENTDISTRIBUTIVE(vgs, card)
-	case "ELEMENTCOMMUTATIVE":
-		return theorems.ELEMENTCOMMUTATIVE(vgs, card)
-	case "PYTHAGOREANTHEOREM":
-		return theorems.PYTHAGOREANTHEOREM(vgs, card)
-	case "PASCALTRIANGLE":
-		return theorems.PASCALTRIANGLE(vgs, card)
-	case "FUNDAMENTALTHEOREMOFARITHMETIC":
-		return theorems.FUNDAMENTALTHEOREMOFARITHMETIC(vgs, card)
-	// constants
-	case "CONSTE":
-		return constants.CONSTE(vgs, card)
-	case "CONSTN1":
-		return constants.CONSTN1(vgs, card)
-	case "CONST73":
-		return constants.CONST73(vgs, card)
-	case "CONSTGOOGLE":
-		return constants.CONSTGOOGLE(vgs, card)
-	case "CONST42":
-		return constants.CONST42(vgs, card)
-	case "CONSTPHI":
-		return constants.CONSTPHI(vgs, card)
-	case "CONSTZERO":
-		return constants.CONSTZERO(vgs, card)
-	case "CONSTPI":
-		return constants.CONSTPI(vgs, card)
-	case "CONST7":
-		return constants.CONST7(vgs, card)
-	case "CONST26":
-		return constants.CONST26(vgs, card)
-	case "CONST6":
-		return constants.CONST6(vgs, card)
-	case "CONSTFIBONACCI":
-		return constants.CONSTFIBONACCI(vgs, card)
-	case "CONST69":
-		return constants.CONST69(vgs, card)
-	case "CONSTTAU":
-		return constants.CONSTTAU(vgs, card)
-	case "CONSTTENPOWER":
-		return constants.CONSTTENPOWER(vgs, card)
-	case "CONSTGRAHAM":
-		return constants.CONSTGRAHAM(vgs, card)
-	case "CONSTCUPID":
-		return constants.CONSTCUPID(vgs, card)
-	default:
-		return fmt.Errorf("unknown card method %s", card.Method)
-	}
+	return m.Apply(vgs, card)
 }
@@ -2,22 +2,18 @@ package constants
 
 import (
 	"fmt"
-	"math"
 	"math/big"
 
+	"github.com/umarbektokyo/matetra-engine/bigmath"
 	"github.com/umarbektokyo/matetra-engine/model"
 	"github.com/umarbektokyo/matetra-engine/utils"
 )
 
-func AddConstant(vgs *model.GameState, player int, value *big.Float, mark string) error {
-	fmt.Printf("[DEBUG] AddConstant: Adding %s to player %d\n", value.Text('g', 10), player)
+func AddConstant(vgs *model.GameState, player int, value model.Number) error {
 	for i := range vgs.Numbers[player] {
 		// prefer an empty slot
 		if vgs.Numbers[player][i].Mark == "n" {
-			fmt.Printf("[DEBUG] AddConstant: Found empty slot at %d\n", i)
-			a := &vgs.Numbers[player][i]
-			a.Value = value
-			a.Mark = mark
+			vgs.Numbers[player][i] = value
 			return nil
 		}
 	}
@@ -25,20 +21,30 @@ func AddConstant(vgs *model.GameState, player int, value *big.Float, mark string
 	// resort to replacing smallest value
 	minIdx := 0
 	for i := 1; i < len(vgs.Numbers[player]); i++ {
-		if vgs.Numbers[player][i].Value.Cmp(vgs.Numbers[player][minIdx].Value) < 0 {
+		if vgs.Numbers[player][i].Cmp(&vgs.Numbers[player][minIdx]) < 0 {
 			minIdx = i
 		}
 	}
 
-	a := &vgs.Numbers[player][minIdx]
-	a.Value = value
-	a.Mark = mark
+	vgs.Numbers[player][minIdx] = value
 
 	return nil
 }
 
+// Inexact constant shown by name in the number's expression
+func namedConstant(value *big.Float, name string) model.Number {
+	n := model.FloatNumber(value)
+	n.Expr = model.ConstExpr(name)
+	return n
+}
+
+// Integer constant, exact in exact mode
+func intConstant(vgs *model.GameState, v int64) model.Number {
+	return model.IntNumber(v, vgs.Settings.Exact, utils.Precision(vgs))
+}
+
 func DICE(vgs *model.GameState, player int) error {
-	return AddConstant(vgs, player, big.NewFloat(float64(utils.RollDice(6))), "")
+	return AddConstant(vgs, player, intConstant(vgs, int64(utils.RollDice(vgs, 6))))
 }
 
 func DICEAtSlot(vgs *model.GameState, player int, slotIndex int) error {
@@ -46,51 +52,46 @@ func DICEAtSlot(vgs *model.GameState, player int, slotIndex int) error {
 		return fmt.Errorf("invalid slot index: %d", slotIndex)
 	}
 
-	diceValue := big.NewFloat(float64(utils.RollDice(6)))
-	fmt.Printf("[DEBUG] DICEAtSlot: Rolling dice for player %d at slot %d, value: %s\n",
-		player, slotIndex, diceValue.Text('g', 10))
-
-	vgs.Numbers[player][slotIndex].Value = diceValue
-	vgs.Numbers[player][slotIndex].Mark = ""
+	vgs.Numbers[player][slotIndex] = intConstant(vgs, int64(utils.RollDice(vgs, 6)))
 
 	return nil
 }
 
 func CONSTPI(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(math.Pi), "")
+	return AddConstant(vgs, card.Owner, namedConstant(bigmath.Pi(utils.Precision(vgs)), "pi"))
 }
 
 func CONSTE(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(math.E), "")
+	return AddConstant(vgs, card.Owner, namedConstant(bigmath.E(utils.Precision(vgs)), "e"))
 }
 
 func CONSTN1(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(-1), "")
+	return AddConstant(vgs, card.Owner, intConstant(vgs, -1))
 }
 
 func CONST73(vgs *model.GameState, card *model.Card) error {
-	value := big.NewFloat(73)
+	value := intConstant(vgs, 73)
 	exists73 := false
 
 	for _, num := range vgs.Numbers[card.Owner] {
-		if num.Value != nil && num.Value.Cmp(value) == 0 {
+		if num.Value != nil && num.Cmp(&value) == 0 {
 			exists73 = true
 			break
 		}
 	}
 
 	if !exists73 {
-		value = big.NewFloat(12)
+		value = intConstant(vgs, 12)
 	}
 
-	return AddConstant(vgs, card.Owner, value, "")
+	return AddConstant(vgs, card.Owner, value)
 }
 
 func CONSTGOOGLE(vgs *model.GameState, card *model.Card) error {
 	attackedPlayer := card.Inputs[0]
 	attackedIndex := card.Inputs[1]
 
-	ten := big.NewFloat(10)
+	ten := intConstant(vgs, 10)
 
 	if attackedPlayer >= 0 &&
 		attackedPlayer < len(vgs.Numbers) &&
@@ -100,87 +101,100 @@ func CONSTGOOGLE(vgs *model.GameState, card *model.Card) error {
 		num := &vgs.Numbers[attackedPlayer][attackedIndex]
 
 		if num.Value != nil {
-			q := new(big.Float).Quo(num.Value, ten)
+			q := num.Clone()
+			q.Quo(&ten)
 			if q.IsInt() {
 				// steal
-				_ = AddConstant(vgs, card.Owner, new(big.Float).Set(num.Value), "")
-				num.Value = big.NewFloat(0)
-				num.Mark = "n"
+				stolen := num.Clone()
+				stolen.Mark = ""
+				_ = AddConstant(vgs, card.Owner, stolen)
+				num.Clear()
 				return nil
 			}
 		}
 	}
 
 	// default behavior
-	return AddConstant(vgs, card.Owner, ten, "")
+	return AddConstant(vgs, card.Owner, ten)
 }
 
 func CONST42(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(42), "")
+	return AddConstant(vgs, card.Owner, intConstant(vgs, 42))
 }
 
 func CONSTPHI(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(math.Phi), "")
+	return AddConstant(vgs, card.Owner, namedConstant(bigmath.Phi(utils.Precision(vgs)), "phi"))
 }
 
 func CONSTZERO(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(0), "")
+	return AddConstant(vgs, card.Owner, intConstant(vgs, 0))
 }
 
 func CONST7(vgs *model.GameState, card *model.Card) error {
-	if err := AddConstant(vgs, card.Owner, big.NewFloat(7), ""); err != nil {
+	if err := AddConstant(vgs, card.Owner, intConstant(vgs, 7)); err != nil {
 		return err
 	}
 
-	r1, r2 := utils.RollDice(6), utils.RollDice(6)
+	r1, r2 := utils.RollDice(vgs, 6), utils.RollDice(vgs, 6)
 	if r1+r2 == 7 {
-		return AddConstant(vgs, card.Owner, big.NewFloat(7), "")
+		return AddConstant(vgs, card.Owner, intConstant(vgs, 7))
 	}
 	return nil
 }
 
 func CONST26(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(26), "")
+	return AddConstant(vgs, card.Owner, intConstant(vgs, 26))
 }
 
 func CONST6(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(6), "")
+	return AddConstant(vgs, card.Owner, intConstant(vgs, 6))
 }
 
 func CONSTFIBONACCI(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(1), "F")
+	value := intConstant(vgs, 1)
+	value.Mark = "F"
+	return AddConstant(vgs, card.Owner, value)
 }
 
 func CONST69(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(69), "")
+	return AddConstant(vgs, card.Owner, intConstant(vgs, 69))
 }
 
 func CONSTTAU(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(math.Pi*2), "")
+	prec := utils.Precision(vgs)
+	tau := bigmath.Pi(prec)
+	tau.Mul(tau, big.NewFloat(2))
+	return AddConstant(vgs, card.Owner, namedConstant(tau, "tau"))
 }
 
 func CONSTTENPOWER(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(math.Pow(10, float64(utils.RollDice(6)))), "")
+	dice := int64(utils.RollDice(vgs, 6))
+	power := new(big.Int).Exp(big.NewInt(10), big.NewInt(dice), nil)
+	value := model.BigIntNumber(power, vgs.Settings.Exact, utils.Precision(vgs))
+	value.Expr = model.NewExpr(model.OpPow, model.NumExpr("10"), model.NumExpr(fmt.Sprint(dice)))
+	return AddConstant(vgs, card.Owner, value)
 }
 
 func CONSTGRAHAM(vgs *model.GameState, card *model.Card) error {
-	return AddConstant(vgs, card.Owner, big.NewFloat(9), "")
+	return AddConstant(vgs, card.Owner, intConstant(vgs, 9))
 }
 
 func CONSTCUPID(vgs *model.GameState, card *model.Card) error {
-	roll1, roll2 := utils.RollDice(6), utils.RollDice(6)
+	roll1, roll2 := utils.RollDice(vgs, 6), utils.RollDice(vgs, 6)
 	if roll1 <= 3 && roll2 <= 3 {
-		return AddConstant(vgs, card.Owner, big.NewFloat(29), "")
+		return AddConstant(vgs, card.Owner, intConstant(vgs, 29))
 	}
-	return AddConstant(vgs, card.Owner, big.NewFloat(14), "")
+	return AddConstant(vgs, card.Owner, intConstant(vgs, 14))
 }
 
 func FACTORIAL(vgs *model.GameState, card *model.Card) error {
-	dice := utils.RollDice(6)
+	dice := utils.RollDice(vgs, 6)
 	result := big.NewInt(1)
 	for i := int64(2); i <= int64(dice); i++ {
 		result.Mul(result, big.NewInt(i))
 	}
 
-	return AddConstant(vgs, card.Owner, new(big.Float).SetInt(result), "")
+	value := model.BigIntNumber(result, vgs.Settings.Exact, utils.Precision(vgs))
+	value.Expr = model.NewExpr(model.OpFact, model.NumExpr(fmt.Sprint(dice)))
+	return AddConstant(vgs, card.Owner, value)
 }
@@ -0,0 +1,327 @@
+package cards
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/umarbektokyo/matetra-engine/cards/formula"
+)
+
+// Method of the cards whose effect is the formula in their SVG column
+const FormulaMethod = "FORMULA"
+
+// Precedence of a card added by a deck file without a Precedence column
+const DefaultPrecedence = 5
+
+// Columns of a deck file, in the order of the embedded csv
+var columns = []string{"Name", "SVG", "Description", "Type", "Method", "InputsReq", "Count", "Pack", "Precedence"}
+
+// One row of a deck file, every copy of the card is built from it
+type Definition struct {
+	Name        string
+	SVG         string
+	Description string
+	Type        string
+	Method      string
+	InputsReq   string
+	Count       int
+	Pack        string
+	Precedence  int
+	source      string // where the row came from, for errors
+}
+
+// The deck every game is built from, the embedded csv until LoadFile replaces it
+var (
+	deckMu   sync.RWMutex
+	deck     []Definition
+	deckErr  error // the embedded csv failed to load
+	deckOnce sync.Once
+)
+
+// A row as read from a file: only the columns the file has are set
+type row struct {
+	where  string // "cards.csv row 7" or "deck.json entry 3"
+	fields map[string]string
+}
+
+// Returns the definitions of the current deck, the embedded csv is parsed
+// the first time
+func definitions() ([]Definition, error) {
+	deckOnce.Do(func() {
+		rows, err := parseCSV("cards.csv", CardsCSV)
+		var defs []Definition
+		if err == nil {
+			defs, err = merge(nil, rows)
+		}
+		deckMu.Lock()
+		deck, deckErr = defs, err
+		deckMu.Unlock()
+	})
+
+	deckMu.RLock()
+	defer deckMu.RUnlock()
+	return deck, deckErr
+}
+
+// Short hash of the current deck, decks with the same fingerprint build the
+// same cards. Journals record it so a replay can tell it has the wrong deck.
+func Fingerprint() (string, error) {
+	defs, err := definitions()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, def := range defs {
+		fmt.Fprintf(h, "%q,%q,%q,%q,%q,%q,%d,%q,%d\n",
+			def.Name, def.SVG, def.Description, def.Type, def.Method, def.InputsReq, def.Count, def.Pack, def.Precedence)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// Overrides or extends the deck with the cards in a .csv or .json file.
+// Rows are matched to the deck by Method: a known method only changes the
+// columns the row has, a new one adds a card. Games started afterwards use
+// the new deck.
+func LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(path)
+	var rows []row
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		rows, err = parseJSON(name, data)
+	} else {
+		rows, err = parseCSV(name, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	base, err := definitions()
+	if err != nil {
+		return err
+	}
+	defs, err := merge(base, rows)
+	if err != nil {
+		return err
+	}
+
+	deckMu.Lock()
+	deck = defs
+	deckMu.Unlock()
+	return nil
+}
+
+// Returns the canonical column name, false for an unknown column
+func column(name string) (string, bool) {
+	for _, col := range columns {
+		if strings.EqualFold(strings.TrimSpace(name), col) {
+			return col, true
+		}
+	}
+	return "", false
+}
+
+// Reads a csv with a header row, the columns can come in any order
+func parseCSV(source string, data []byte) ([]row, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s: missing the header row", source)
+	}
+
+	header := make([]string, len(records[0]))
+	for i, name := range records[0] {
+		col, ok := column(name)
+		if !ok {
+			return nil, fmt.Errorf("%s row 1, column %d: unknown column %q, expected one of %s", source, i+1, name, strings.Join(columns, ", "))
+		}
+		header[i] = col
+	}
+
+	rows := make([]row, 0, len(records)-1)
+	for i, record := range records[1:] {
+		r := row{where: fmt.Sprintf("%s row %d", source, i+2), fields: map[string]string{}}
+		for j, value := range record {
+			r.fields[header[j]] = value
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// Reads a json array of objects keyed by column name
+func parseJSON(source string, data []byte) ([]row, error) {
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: expected an array of cards: %v", source, err)
+	}
+
+	rows := make([]row, 0, len(entries))
+	for i, entry := range entries {
+		r := row{where: fmt.Sprintf("%s entry %d", source, i+1), fields: map[string]string{}}
+		for key, value := range entry {
+			col, ok := column(key)
+			if !ok {
+				return nil, fmt.Errorf("%s, column %s: unknown column, expected one of %s", r.where, key, strings.Join(columns, ", "))
+			}
+			switch v := value.(type) {
+			case string:
+				r.fields[col] = v
+			case float64:
+				r.fields[col] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				return nil, fmt.Errorf("%s, column %s: expected a string or a number", r.where, key)
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// Applies the rows on top of base, by Method (and Name for formula cards),
+// and checks the result
+func merge(base []Definition, rows []row) ([]Definition, error) {
+	defs := append([]Definition(nil), base...)
+	index := make(map[string]int, len(defs))
+	for i, def := range defs {
+		index[key(def.Method, def.Name)] = i
+	}
+
+	for _, r := range rows {
+		method, ok := r.fields["Method"]
+		if !ok || method == "" {
+			return nil, fmt.Errorf("%s, column Method: every card needs a method", r.where)
+		}
+		if _, ok := r.fields["Name"]; method == FormulaMethod && !ok {
+			return nil, fmt.Errorf("%s, column Name: formula cards are told apart by name", r.where)
+		}
+
+		k := key(method, r.fields["Name"])
+		i, known := index[k]
+		if !known {
+			for _, col := range []string{"Name", "Count", "Pack"} {
+				if _, ok := r.fields[col]; !ok {
+					return nil, fmt.Errorf("%s, column %s: required for the new card %s", r.where, col, method)
+				}
+			}
+			def := Definition{Method: method, Precedence: DefaultPrecedence}
+			defs = append(defs, def)
+			i = len(defs) - 1
+			index[k] = i
+		}
+
+		def := &defs[i]
+		def.source = r.where
+		if err := def.set(r.fields); err != nil {
+			return nil, fmt.Errorf("%s, %v", r.where, err)
+		}
+		// InputsReq can be left out, it follows from the method
+		if _, ok := r.fields["InputsReq"]; !ok {
+			if inputs, err := def.expectedInputs(); err == nil {
+				def.InputsReq = inputs
+			}
+		}
+	}
+
+	for _, def := range defs {
+		if err := def.check(); err != nil {
+			return nil, fmt.Errorf("%s, %v", def.source, err)
+		}
+	}
+	return defs, nil
+}
+
+// Rows override the card with the same method, every formula card has its own
+func key(method, name string) string {
+	if method == FormulaMethod {
+		return method + ":" + name
+	}
+	return method
+}
+
+// Copies the given columns into the definition
+func (def *Definition) set(fields map[string]string) error {
+	for col, value := range fields {
+		switch col {
+		case "Name":
+			def.Name = value
+		case "SVG":
+			def.SVG = value
+		case "Description":
+			def.Description = value
+		case "Type":
+			def.Type = value
+		case "Method":
+			def.Method = value
+		case "InputsReq":
+			def.InputsReq = value
+		case "Pack":
+			def.Pack = value
+		case "Count", "Precedence":
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("column %s: %q is not a whole number", col, value)
+			}
+			if col == "Count" {
+				def.Count = n
+			} else {
+				def.Precedence = n
+			}
+		}
+	}
+	return nil
+}
+
+// Checks the definition against its registered method
+func (def *Definition) check() error {
+	switch {
+	case def.Name == "":
+		return fmt.Errorf("column Name: the card has no name")
+	case def.Pack == "":
+		return fmt.Errorf("column Pack: card %s has no pack", def.Name)
+	case def.Count < 0:
+		return fmt.Errorf("column Count: card %s has a negative count", def.Name)
+	}
+
+	inputs, err := def.expectedInputs()
+	if err != nil {
+		return err
+	}
+	if def.InputsReq != inputs {
+		return fmt.Errorf("column InputsReq: card %s has %q but %s reads %q", def.Name, def.InputsReq, def.Method, inputs)
+	}
+	return nil
+}
+
+// InputsReq the card's method reads, a formula card's depends on its formula
+func (def *Definition) expectedInputs() (string, error) {
+	m, ok := Lookup(def.Method)
+	if !ok {
+		return "", fmt.Errorf("column Method: card %s uses unknown method %s", def.Name, def.Method)
+	}
+	if def.Method != FormulaMethod {
+		return m.Inputs, nil
+	}
+
+	f, err := formula.Parse(def.SVG)
+	if err != nil {
+		return "", fmt.Errorf("column SVG: card %s: %v", def.Name, err)
+	}
+	return f.Inputs(), nil
+}
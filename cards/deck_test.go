@@ -0,0 +1,60 @@
+package cards
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefinitionsParsedOnce(t *testing.T) {
+	first, err := definitions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := definitions()
+	if len(first) == 0 || &first[0] != &second[0] {
+		t.Errorf("the embedded deck was parsed again")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base, err := definitions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := parseCSV("deck.csv", []byte("Method,Count\nADD,6\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs, err := merge(base, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, def := range defs {
+		if def.Method != "ADD" {
+			continue
+		}
+		if def.Count != 6 {
+			t.Errorf("ADD count %d, want 6", def.Count)
+		}
+		if base[i].Count == 6 {
+			t.Errorf("merge changed the base deck")
+		}
+	}
+
+	errors := map[string]string{
+		"Method,Count\nADD,six\n":                        "deck.csv row 2, column Count",
+		"Name,Method,Count,Pack\nX,NOPE,1,Core\n":        "unknown method NOPE",
+		"Method,InputsReq\nADD,An\n":                     "column InputsReq",
+		"Name,SVG,Method,Count,Pack\nF,a+,FORMULA,1,X\n": "column SVG",
+	}
+	for csv, want := range errors {
+		rows, err := parseCSV("deck.csv", []byte(csv))
+		if err == nil {
+			_, err = merge(base, rows)
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want an error with %q", csv, err, want)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Package formula evaluates the cards whose effect is written as a formula in
+// the SVG column of the deck, so a new arithmetic card needs no Go code.
+//
+// A formula reads the variables
+//
+//	a: a number of the defending player, replaced by the result
+//	b, c: numbers of the card's owner, used up
+//	d: a die rolled when the card resolves, the same roll for every d
+//
+// and supports + - * / ^, implicit multiplication ("ad^2+bd+c"), |x|, x!,
+// grouping with () or [], the constants e, pi, phi and tau and the functions
+// sqrt, abs, exp, ln, log or log10, log_base, sin, cos and tan. A formula
+// without a puts its result in the owner's row like a constant card.
+package formula
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/umarbektokyo/matetra-engine/bigmath"
+	"github.com/umarbektokyo/matetra-engine/cards/constants"
+	"github.com/umarbektokyo/matetra-engine/model"
+	"github.com/umarbektokyo/matetra-engine/utils"
+)
+
+// Variables a formula can read, in the order of their inputs
+const Variables = "abcd"
+
+// Largest integer a factorial is taken of
+const maxFactorial = 1000
+
+// Largest exponent an exact fraction is raised to, bigger ones fall back to floats
+const maxExactPower = 64
+
+type Formula struct {
+	Source string
+	root   *node
+	uses   map[string]bool
+}
+
+// Formulas are parsed once and shared between every copy of a card
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]*Formula{}
+)
+
+// Parses a formula, errors point to the column of the formula
+func Parse(src string) (*Formula, error) {
+	cacheMu.Lock()
+	f, ok := cache[src]
+	cacheMu.Unlock()
+	if ok {
+		return f, nil
+	}
+
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("the formula is empty")
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	root, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorAt(p.peek(), "unexpected")
+	}
+
+	f = &Formula{Source: src, root: root, uses: map[string]bool{}}
+	f.collect(root)
+
+	cacheMu.Lock()
+	cache[src] = f
+	cacheMu.Unlock()
+	return f, nil
+}
+
+func (f *Formula) collect(n *node) {
+	if n.kind == nodeVar {
+		f.uses[n.text] = true
+	}
+	for _, arg := range n.args {
+		f.collect(arg)
+	}
+}
+
+// Reports whether the formula reads the variable
+func (f *Formula) Uses(variable string) bool {
+	return f.uses[variable]
+}
+
+// InputsReq of a card with this formula: An for a, Un for b and for c
+func (f *Formula) Inputs() string {
+	inputs := ""
+	if f.Uses("a") {
+		inputs += "An"
+	}
+	for _, v := range []string{"b", "c"} {
+		if f.Uses(v) {
+			inputs += "Un"
+		}
+	}
+	return inputs
+}
+
+// Values of the variables and how numbers are made
+type Env struct {
+	Vars  map[string]model.Number
+	Exact bool // literals are exact fractions
+	Prec  uint
+}
+
+// Evaluates the formula, the result's expression shows how it was derived
+func (f *Formula) Eval(env Env) (model.Number, error) {
+	return env.eval(f.root)
+}
+
+func (env Env) eval(n *node) (model.Number, error) {
+	switch n.kind {
+	case nodeNum:
+		return env.literal(n.text)
+	case nodeVar:
+		v, ok := env.Vars[n.text]
+		if !ok {
+			return model.Number{}, fmt.Errorf("variable %s has no value", n.text)
+		}
+		return v.Clone(), nil
+	case nodeConst:
+		return env.constant(n.text), nil
+	}
+
+	args := make([]model.Number, len(n.args))
+	for i, arg := range n.args {
+		v, err := env.eval(arg)
+		if err != nil {
+			return model.Number{}, err
+		}
+		args[i] = v
+	}
+	if n.kind == nodeUnary {
+		return env.unary(n.op, args[0])
+	}
+	if n.op == "^" && n.args[0].kind == nodeConst && n.args[0].text == "e" {
+		return env.exp(args[1])
+	}
+	return env.binary(n.op, args[0], args[1])
+}
+
+func (env Env) literal(text string) (model.Number, error) {
+	if env.Exact {
+		r, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return model.Number{}, fmt.Errorf("invalid number %q", text)
+		}
+		return model.RatNumber(r, env.Prec), nil
+	}
+	v, ok := new(big.Float).SetPrec(env.Prec).SetString(text)
+	if !ok {
+		return model.Number{}, fmt.Errorf("invalid number %q", text)
+	}
+	return model.FloatNumber(v), nil
+}
+
+func (env Env) constant(name string) model.Number {
+	var v *big.Float
+	switch name {
+	case "e":
+		v = bigmath.E(env.Prec)
+	case "pi":
+		v = bigmath.Pi(env.Prec)
+	case "phi":
+		v = bigmath.Phi(env.Prec)
+	case "tau":
+		v = bigmath.Pi(env.Prec)
+		v.Mul(v, big.NewFloat(2))
+	}
+	n := model.FloatNumber(v)
+	n.Expr = model.ConstExpr(name)
+	return n
+}
+
+func (env Env) unary(op string, x model.Number) (model.Number, error) {
+	switch op {
+	case "+":
+		return x, nil
+	case "-":
+		x.Neg()
+		return x, nil
+	case "abs":
+		x.Abs()
+		return x, nil
+	case "!":
+		return env.factorial(x)
+	}
+
+	var (
+		result *big.Float
+		err    error
+		exprOp string
+	)
+	switch op {
+	case "sqrt":
+		if x.Sign() < 0 {
+			return model.Number{}, fmt.Errorf("cannot take a square root a negative number")
+		}
+		result, exprOp = new(big.Float).SetPrec(env.Prec).Sqrt(x.Value), model.OpSqrt
+	case "exp":
+		result, err = bigmath.Exp(x.Value, env.Prec)
+		exprOp = model.OpExp
+	case "ln":
+		result, err = bigmath.Log(x.Value, env.Prec)
+		exprOp = model.OpLn
+	case "log", "log10":
+		result, err = bigmath.Log10(x.Value, env.Prec)
+		exprOp = model.OpLog10
+	case "sin":
+		result, err = bigmath.Sin(x.Value, env.Prec)
+		exprOp = model.OpSin
+	case "cos":
+		result, err = bigmath.Cos(x.Value, env.Prec)
+		exprOp = model.OpCos
+	case "tan":
+		result, err = bigmath.Tan(x.Value, env.Prec)
+		exprOp = model.OpTan
+	default:
+		return model.Number{}, fmt.Errorf("unknown function %s", op)
+	}
+	if err != nil {
+		return model.Number{}, err
+	}
+
+	expr := x.Expression()
+	x.SetFloat(result)
+	x.Expr = model.NewExpr(exprOp, expr)
+	return x, nil
+}
+
+func (env Env) factorial(x model.Number) (model.Number, error) {
+	i, ok := x.Int()
+	if !ok || i.Sign() < 0 || i.Cmp(big.NewInt(maxFactorial)) > 0 {
+		return model.Number{}, fmt.Errorf("factorial needs an integer 0..%d, got %s", maxFactorial, x.String())
+	}
+
+	result := new(big.Int).MulRange(1, i.Int64())
+	n := model.BigIntNumber(result, env.Exact && x.IsExact(), env.Prec)
+	n.Expr = model.NewExpr(model.OpFact, x.Expression())
+	return n, nil
+}
+
+func (env Env) binary(op string, x, y model.Number) (model.Number, error) {
+	switch op {
+	case "+":
+		x.Add(&y)
+	case "-":
+		x.Sub(&y)
+	case "*":
+		x.Mul(&y)
+	case "/":
+		if y.Sign() == 0 {
+			return model.Number{}, fmt.Errorf("cannot divide by zero")
+		}
+		x.Quo(&y)
+	case "^":
+		return env.pow(x, y)
+	case "log":
+		result, err := bigmath.LogBase(x.Value, y.Value, env.Prec)
+		if err != nil {
+			return model.Number{}, err
+		}
+		expr := model.NewExpr(model.OpLog, x.Expression(), y.Expression())
+		x.SetFloat(result)
+		x.Expr = expr
+	default:
+		return model.Number{}, fmt.Errorf("unknown operator %s", op)
+	}
+	return x, nil
+}
+
+// x^y, an exact fraction stays exact under a small integer power
+func (env Env) pow(x, y model.Number) (model.Number, error) {
+	expr := model.NewExpr(model.OpPow, x.Expression(), y.Expression())
+
+	if x.IsExact() && y.IsExact() && y.IsInt() {
+		n, _ := y.Int()
+		if n.IsInt64() && n.Int64() >= -maxExactPower && n.Int64() <= maxExactPower {
+			exp := n.Int64()
+			if exp < 0 {
+				if x.Sign() == 0 {
+					return model.Number{}, fmt.Errorf("cannot divide by zero")
+				}
+				x.Inv()
+				exp = -exp
+			}
+			power := big.NewInt(exp)
+			num := new(big.Int).Exp(x.Rat.Num(), power, nil)
+			den := new(big.Int).Exp(x.Rat.Denom(), power, nil)
+			x.SetRat(new(big.Rat).SetFrac(num, den))
+			x.Expr = expr
+			return x, nil
+		}
+	}
+
+	result, err := bigmath.Pow(x.Value, y.Value, env.Prec)
+	if err != nil {
+		return model.Number{}, err
+	}
+	x.SetFloat(result)
+	x.Expr = expr
+	return x, nil
+}
+
+// e^y, shown as a power like the card
+func (env Env) exp(y model.Number) (model.Number, error) {
+	result, err := bigmath.Exp(y.Value, env.Prec)
+	if err != nil {
+		return model.Number{}, err
+	}
+	n := model.FloatNumber(result)
+	n.Expr = model.NewExpr(model.OpPow, model.ConstExpr("e"), y.Expression())
+	return n, nil
+}
+
+// Card method behind FORMULA rows, the formula comes from card.Formula
+func Apply(vgs *model.GameState, card *model.Card) error {
+	f, err := Parse(card.Formula)
+	if err != nil {
+		return fmt.Errorf("card %s has an invalid formula: %v", card.Name, err)
+	}
+
+	// inputs follow Inputs(): a, then b, then c, two per number. An empty
+	// number reads as 0, the same as in the built-in cards.
+	vars := map[string]model.Number{}
+	slots := map[string][2]int{}
+	next := 0
+	for _, v := range []string{"a", "b", "c"} {
+		if !f.Uses(v) {
+			continue
+		}
+		player, index := card.Inputs[next], card.Inputs[next+1]
+		next += 2
+		for other, slot := range slots {
+			if slot == [2]int{player, index} {
+				return fmt.Errorf("%s and %s must be different numbers", other, v)
+			}
+		}
+		slots[v] = [2]int{player, index}
+		vars[v] = vgs.Numbers[player][index].Clone()
+	}
+
+	prec := utils.Precision(vgs)
+	if f.Uses("d") {
+		dice := utils.RollDice(vgs, 6)
+		vars["d"] = model.IntNumber(int64(dice), vgs.Settings.Exact, prec)
+	}
+
+	result, err := f.Eval(Env{Vars: vars, Exact: vgs.Settings.Exact, Prec: prec})
+	if err != nil {
+		return err
+	}
+	if result.Value.IsInf() {
+		return fmt.Errorf("the result is too large")
+	}
+
+	for _, v := range []string{"b", "c"} {
+		if slot, ok := slots[v]; ok {
+			vgs.Numbers[slot[0]][slot[1]].Clear()
+		}
+	}
+
+	slot, ok := slots["a"]
+	if !ok {
+		result.Mark = ""
+		return constants.AddConstant(vgs, card.Owner, result)
+	}
+	a := &vgs.Numbers[slot[0]][slot[1]]
+	result.Mark = a.Mark
+	*a = result
+	return nil
+}
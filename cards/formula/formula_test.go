@@ -0,0 +1,240 @@
+package formula
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/cards/functions"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+const prec = 128
+
+func eval(t *testing.T, src string, vars map[string]float64, exact bool) (model.Number, error) {
+	t.Helper()
+	f, err := Parse(src)
+	if err != nil {
+		return model.Number{}, err
+	}
+	env := Env{Vars: map[string]model.Number{}, Exact: exact, Prec: prec}
+	for name, v := range vars {
+		env.Vars[name] = model.FloatNumber(new(big.Float).SetPrec(prec).SetFloat64(v))
+	}
+	return f.Eval(env)
+}
+
+func TestEval(t *testing.T) {
+	vars := map[string]float64{"a": 2, "b": -3, "c": 5, "d": 4}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		// precedence and associativity
+		{"2+3*4", 14},
+		{"2*3+4", 10},
+		{"10-4-3", 3},
+		{"24/4/2", 3},
+		{"2^3^2", 512},
+		{"(2^3)^2", 64},
+		{"2*3^2", 18},
+		// unary minus binds looser than a power
+		{"-2^2", -4},
+		{"(-2)^2", 4},
+		{"--3", 3},
+		{"2*-3", -6},
+		{"-a+b", -5},
+		// implicit multiplication
+		{"2a", 4},
+		{"ad^2+bd+c", 25},
+		{"a(b+c)", 4},
+		{"2[1+1]", 4},
+		{"3pi", 3 * math.Pi},
+		// absolute values
+		{"|b|", 3},
+		{"|a+b|c", 5},
+		{"|b||b|", 9},
+		{"|2-|b||", 1},
+		{"abs(b)", 3},
+		// postfix and functions
+		{"3!", 6},
+		{"a!+1", 3},
+		{"sqrt(16)", 4},
+		{"sqrt[c^2-16]", 3},
+		{"log(100)", 2},
+		{"log10(1000)", 3},
+		{"log_2[8]", 3},
+		{"log_a(16)", 4},
+		{"ln(e)", 1},
+		{"ln(1)", 0},
+		{"e^0", 1},
+		{"exp(1)", math.E},
+		{"sin(0)+cos(0)", 1},
+		{"tan(pi/4)", 1},
+		{"tau/pi", 2},
+		{"phi^2-phi", 1},
+	}
+	for _, tt := range tests {
+		got, err := eval(t, tt.src, vars, false)
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		f, _ := got.Value.Float64()
+		if math.Abs(f-tt.want) > 1e-12*math.Max(1, math.Abs(tt.want)) {
+			t.Errorf("%s = %v, want %v", tt.src, f, tt.want)
+		}
+	}
+}
+
+func TestEvalExact(t *testing.T) {
+	got, err := eval(t, "1/3+1/6", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsExact() || got.Rat.Cmp(big.NewRat(1, 2)) != 0 {
+		t.Errorf("1/3+1/6 = %s, want exactly 1/2", got.String())
+	}
+
+	got, err = eval(t, "(2/3)^-2", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsExact() || got.Rat.Cmp(big.NewRat(9, 4)) != 0 {
+		t.Errorf("(2/3)^-2 = %s, want exactly 9/4", got.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{"", "empty"},
+		{"2+", "column 3"},
+		{"2+*3", "column 3"},
+		{"(2", "expected ')'"},
+		{"[2)", "expected ']'"},
+		{"|2", "expected '|'"},
+		{"x", "unknown name"},
+		{"2..3", "invalid number"},
+		{"2#3", "column 2"},
+		{"sqrt 2", "expected ( or ["},
+		{"2)", "unexpected"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want an error with %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, src := range []string{"1/0", "1/(2-2)", "(0-1)!", "2.5!", "1001!", "sqrt(0-1)", "ln(0)", "log_1(5)", "0^(0-1)", "(0-2)^0.5"} {
+		if got, err := eval(t, src, nil, false); err == nil {
+			t.Errorf("%s = %s, want an error", src, got.String())
+		}
+	}
+	if _, err := eval(t, "a+1", nil, false); err == nil {
+		t.Errorf("a variable without a value should fail")
+	}
+}
+
+func TestInputs(t *testing.T) {
+	tests := map[string]string{
+		"a+b":       "AnUn",
+		"ad^2+bd+c": "AnUnUn",
+		"b*c":       "UnUn",
+		"d+1":       "",
+		"a^2":       "An",
+	}
+	for src, want := range tests {
+		f, err := Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.Inputs(); got != want {
+			t.Errorf("%s reads %q, want %q", src, got, want)
+		}
+	}
+}
+
+// Two players with a row of three numbers each, the second slot of the
+// owner's row is empty
+func gameState(exact bool) *model.GameState {
+	vgs := &model.GameState{
+		Players:  []model.Player{{Name: "ann"}, {Name: "bob"}},
+		Settings: model.Settings{Exact: exact, Precision: prec},
+		RNG:      model.NewRNG(1),
+	}
+	for p := 0; p < 2; p++ {
+		row := make([]model.Number, 3)
+		for i := range row {
+			row[i] = model.IntNumber(int64(10*p+i+1), exact, prec)
+		}
+		vgs.Numbers = append(vgs.Numbers, row)
+	}
+	vgs.Numbers[1][1].Clear()
+	return vgs
+}
+
+func value(n model.Number) float64 {
+	f, _ := n.Value.Float64()
+	return f
+}
+
+func TestApply(t *testing.T) {
+	vgs := gameState(true)
+	card := &model.Card{Name: "Quadratic", Owner: 1, Formula: "a*b+c", Inputs: []int{0, 1, 1, 0, 1, 2}}
+	if err := Apply(vgs, card); err != nil {
+		t.Fatal(err)
+	}
+	// a = 2, b = 11, c = 13
+	if got := value(vgs.Numbers[0][1]); got != 35 {
+		t.Errorf("a = %v, want 35", got)
+	}
+	if !vgs.Numbers[0][1].IsExact() {
+		t.Errorf("the result of exact numbers should stay exact")
+	}
+	for _, slot := range []int{0, 2} {
+		if vgs.Numbers[1][slot].Mark != "n" {
+			t.Errorf("b and c should be used up, slot %d is %s", slot, vgs.Numbers[1][slot].String())
+		}
+	}
+
+	// without a the result goes to the owner's empty slot
+	vgs = gameState(false)
+	card = &model.Card{Name: "Double", Owner: 1, Formula: "2b", Inputs: []int{1, 0}}
+	if err := Apply(vgs, card); err != nil {
+		t.Fatal(err)
+	}
+	// b is used up and 22 takes the first empty slot, which is b's
+	if n := vgs.Numbers[1][0]; n.Mark == "n" || value(n) != 22 {
+		t.Errorf("slot 0 is %s, want 22", n.String())
+	}
+	if n := vgs.Numbers[1][1]; n.Mark != "n" {
+		t.Errorf("slot 1 should still be empty, got %s", n.String())
+	}
+
+	card = &model.Card{Name: "Same", Owner: 1, Formula: "b+c", Inputs: []int{1, 2, 1, 2}}
+	if err := Apply(gameState(false), card); err == nil || !strings.Contains(err.Error(), "different numbers") {
+		t.Errorf("the same number twice: %v", err)
+	}
+}
+
+// An empty number reads as 0 in formula cards, the same as in ADD
+func TestApplyEmptyNumberLikeBuiltins(t *testing.T) {
+	formula, builtin := gameState(false), gameState(false)
+	inputs := []int{0, 2, 1, 1}
+
+	if err := Apply(formula, &model.Card{Name: "Addition", Owner: 1, Formula: "a+b", Inputs: inputs}); err != nil {
+		t.Fatal(err)
+	}
+	if err := functions.ADD(builtin, &model.Card{Method: "ADD", Owner: 1, Inputs: inputs}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, want := formula.Numbers[0][2], builtin.Numbers[0][2]
+	if value(got) != value(want) || got.Mark != want.Mark {
+		t.Errorf("formula gives %s (mark %q), ADD gives %s (mark %q)", got.String(), got.Mark, want.String(), want.Mark)
+	}
+}
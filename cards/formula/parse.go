@@ -0,0 +1,305 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Node kinds of a parsed formula
+const (
+	nodeNum    = iota // literal, its digits in text
+	nodeVar           // a, b, c or d
+	nodeConst         // e, pi, phi or tau
+	nodeUnary         // op on args[0]: "-", "+", "!" or a function name
+	nodeBinary        // args[0] op args[1]: "+", "-", "*", "/", "^" or "log" (x, base)
+)
+
+type node struct {
+	kind int
+	op   string
+	text string
+	args []*node
+}
+
+// Functions written as name(x) or name[x]
+var funcNames = map[string]bool{
+	"sqrt": true, "abs": true, "exp": true, "ln": true, "log": true, "log10": true,
+	"sin": true, "cos": true, "tan": true,
+}
+
+var constNames = map[string]bool{"e": true, "pi": true, "phi": true, "tau": true}
+
+type token struct {
+	kind byte // 'n' number, 'v' variable, 'k' constant, 'f' function, or the symbol itself
+	text string
+	pos  int // 1-based column in the formula
+}
+
+// Splits the formula into tokens. Letters that are not a function or a
+// constant are read one by one, so "ad" is a times d.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		ch := rune(src[i])
+		switch {
+		case ch == ' ':
+			i++
+		case unicode.IsDigit(ch) || ch == '.':
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			if strings.Count(src[start:i], ".") > 1 || src[start:i] == "." {
+				return nil, fmt.Errorf("column %d: invalid number %q", start+1, src[start:i])
+			}
+			tokens = append(tokens, token{kind: 'n', text: src[start:i], pos: start + 1})
+		case unicode.IsLetter(ch):
+			start := i
+			for i < len(src) && unicode.IsLetter(rune(src[i])) {
+				i++
+			}
+			// log10 is the one name with digits
+			if src[start:i] == "log" && strings.HasPrefix(src[i:], "10") {
+				i += 2
+			}
+			word := src[start:i]
+			if funcNames[word] {
+				tokens = append(tokens, token{kind: 'f', text: word, pos: start + 1})
+				continue
+			}
+			if constNames[word] {
+				tokens = append(tokens, token{kind: 'k', text: word, pos: start + 1})
+				continue
+			}
+			for j, letter := range word {
+				switch {
+				case strings.ContainsRune(Variables, letter):
+					tokens = append(tokens, token{kind: 'v', text: string(letter), pos: start + j + 1})
+				case letter == 'e':
+					tokens = append(tokens, token{kind: 'k', text: "e", pos: start + j + 1})
+				default:
+					return nil, fmt.Errorf("column %d: unknown name %q, variables are %s", start+1, word, strings.Join(strings.Split(Variables, ""), ", "))
+				}
+			}
+		case strings.ContainsRune("+-*/^!()[]|_", ch):
+			tokens = append(tokens, token{kind: byte(ch), text: string(ch), pos: i + 1})
+			i++
+		default:
+			return nil, fmt.Errorf("column %d: unexpected %q", i+1, ch)
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	abs    int // open |...| around the current token
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{pos: len(p.src) + 1}
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) expect(kind byte) error {
+	t := p.next()
+	if t.kind != kind {
+		return p.errorAt(t, fmt.Sprintf("expected %q", kind))
+	}
+	return nil
+}
+
+func (p *parser) errorAt(t token, msg string) error {
+	if t.kind == 0 {
+		return fmt.Errorf("column %d: %s at the end", t.pos, msg)
+	}
+	return fmt.Errorf("column %d: %s, got %q", t.pos, msg, t.text)
+}
+
+// expr := term (("+" | "-") term)*
+func (p *parser) expr() (*node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == '+' || p.peek().kind == '-' {
+		op := p.next().text
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{kind: nodeBinary, op: op, args: []*node{left, right}}
+	}
+	return left, nil
+}
+
+// term := unary (("*" | "/") unary | power)*, a power right after another
+// factor is multiplied ("2a", "a(b+c)", "ad^2")
+func (p *parser) term() (*node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		var right *node
+		switch t := p.peek(); {
+		case t.kind == '*' || t.kind == '/':
+			op = p.next().text
+			right, err = p.unary()
+		case p.startsFactor(t):
+			op = "*"
+			right, err = p.power()
+		default:
+			return left, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		left = &node{kind: nodeBinary, op: op, args: []*node{left, right}}
+	}
+}
+
+// Reports whether the token can start an implicitly multiplied factor, a "|"
+// inside an absolute value closes it instead
+func (p *parser) startsFactor(t token) bool {
+	switch t.kind {
+	case 'n', 'v', 'k', 'f', '(', '[':
+		return true
+	case '|':
+		return p.abs == 0
+	}
+	return false
+}
+
+// unary := ("-" | "+") unary | power
+func (p *parser) unary() (*node, error) {
+	if t := p.peek(); t.kind == '-' || t.kind == '+' {
+		p.next()
+		arg, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeUnary, op: t.text, args: []*node{arg}}, nil
+	}
+	return p.power()
+}
+
+// power := postfix ("^" unary)?, right associative
+func (p *parser) power() (*node, error) {
+	base, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != '^' {
+		return base, nil
+	}
+	p.next()
+	exp, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &node{kind: nodeBinary, op: "^", args: []*node{base, exp}}, nil
+}
+
+// postfix := primary "!"*
+func (p *parser) postfix() (*node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == '!' {
+		p.next()
+		n = &node{kind: nodeUnary, op: "!", args: []*node{n}}
+	}
+	return n, nil
+}
+
+// primary := number | variable | constant | group | "|" expr "|" | function
+func (p *parser) primary() (*node, error) {
+	t := p.next()
+	switch t.kind {
+	case 'n':
+		return &node{kind: nodeNum, text: t.text}, nil
+	case 'v':
+		return &node{kind: nodeVar, text: t.text}, nil
+	case 'k':
+		return &node{kind: nodeConst, text: t.text}, nil
+	case '(', '[':
+		p.pos--
+		return p.group()
+	case '|':
+		p.abs++
+		arg, err := p.expr()
+		p.abs--
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect('|'); err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeUnary, op: "abs", args: []*node{arg}}, nil
+	case 'f':
+		return p.function(t)
+	}
+	return nil, p.errorAt(t, "expected a number, a variable or a group")
+}
+
+// group := "(" expr ")" | "[" expr "]"
+func (p *parser) group() (*node, error) {
+	open := p.next()
+	closing := byte(')')
+	switch open.kind {
+	case '(':
+	case '[':
+		closing = ']'
+	default:
+		return nil, p.errorAt(open, "expected ( or [")
+	}
+
+	// a group inside an absolute value can hold one of its own
+	abs := p.abs
+	p.abs = 0
+	n, err := p.expr()
+	p.abs = abs
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(closing); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// function := name group | "log" "_" primary group, log without a base is log10
+func (p *parser) function(name token) (*node, error) {
+	if name.text == "log" && p.peek().kind == '_' {
+		p.next()
+		base, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		arg, err := p.group()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeBinary, op: "log", args: []*node{arg, base}}, nil
+	}
+
+	arg, err := p.group()
+	if err != nil {
+		return nil, err
+	}
+	return &node{kind: nodeUnary, op: name.text, args: []*node{arg}}, nil
+}
@@ -2,13 +2,18 @@ package functions
 
 import (
 	"fmt"
-	"math"
 	"math/big"
 
+	"github.com/umarbektokyo/matetra-engine/bigmath"
 	"github.com/umarbektokyo/matetra-engine/model"
 	"github.com/umarbektokyo/matetra-engine/utils"
 )
 
+// Dice roll as it appears in an expression
+func diceExpr(dice int) *model.Expr {
+	return model.NumExpr(fmt.Sprint(dice))
+}
+
 // Input: AnUn
 func ADD(vgs *model.GameState, card *model.Card) error {
 	attackerPlayer := card.Inputs[0]
@@ -22,10 +27,9 @@ func ADD(vgs *model.GameState, card *model.Card) error {
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 	b := &vgs.Numbers[userPlayer][userIndex]
 
-	a.Value.Add(a.Value, b.Value)
+	a.Add(b)
 
-	b.Value = big.NewFloat(0)
-	b.Mark = "n"
+	b.Clear()
 
 	return nil
 }
@@ -43,10 +47,9 @@ func SUBTRACT(vgs *model.GameState, card *model.Card) error {
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 	b := &vgs.Numbers[userPlayer][userIndex]
 
-	a.Value.Sub(a.Value, b.Value)
+	a.Sub(b)
 
-	b.Value = big.NewFloat(0)
-	b.Mark = "n"
+	b.Clear()
 
 	return nil
 }
@@ -64,10 +67,9 @@ func MULTIPLY(vgs *model.GameState, card *model.Card) error {
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 	b := &vgs.Numbers[userPlayer][userIndex]
 
-	a.Value.Mul(a.Value, b.Value)
+	a.Mul(b)
 
-	b.Value = big.NewFloat(0)
-	b.Mark = "n"
+	b.Clear()
 
 	return nil
 }
@@ -85,14 +87,13 @@ func DIVIDE(vgs *model.GameState, card *model.Card) error {
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 	b := &vgs.Numbers[userPlayer][userIndex]
 
-	if b.Value.Cmp(big.NewFloat(0)) == 0 {
+	if b.Sign() == 0 {
 		return fmt.Errorf("Cannot divide by zero")
 	}
 
-	a.Value.Quo(a.Value, b.Value)
+	a.Quo(b)
 
-	b.Value = big.NewFloat(0)
-	b.Mark = "n"
+	b.Clear()
 
 	return nil
 }
@@ -106,12 +107,12 @@ func ABSOLUTEVALUE(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	a.Value.Abs(a.Value)
+	a.Abs()
 
 	return nil
 }
 
-// Input: AnUn
+// Input: An
 func INVERSE(vgs *model.GameState, card *model.Card) error {
 	attackerPlayer := card.Inputs[0]
 	attackerIndex := card.Inputs[1]
@@ -120,11 +121,11 @@ func INVERSE(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	if a.Value.Cmp(big.NewFloat(0)) == 0 {
+	if a.Sign() == 0 {
 		return fmt.Errorf("Cannot divide by zero")
 	}
 
-	a.Value.Quo(big.NewFloat(1), a.Value)
+	a.Inv()
 
 	return nil
 }
@@ -138,7 +139,7 @@ func NEGATIVE(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	a.Value.Mul(a.Value, big.NewFloat(-1))
+	a.Neg()
 
 	return nil
 }
@@ -157,11 +158,13 @@ func SQRT(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	if a.Value.Sign() < 0 {
+	if a.Sign() < 0 {
 		return fmt.Errorf("cannot take a square root a negative number")
 	}
 
-	a.Value.Sqrt(a.Value)
+	expr := a.Expression()
+	a.SetFloat(new(big.Float).SetPrec(utils.Precision(vgs)).Sqrt(a.Value))
+	a.Expr = model.NewExpr(model.OpSqrt, expr)
 
 	return nil
 }
@@ -175,7 +178,9 @@ func SQUARE(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	a.Value.Mul(a.Value, a.Value)
+	expr := a.Expression()
+	a.Mul(a)
+	a.Expr = model.NewExpr(model.OpPow, expr, model.NumExpr("2"))
 
 	return nil
 }
@@ -189,11 +194,16 @@ func COSMOD(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	dice := utils.RollDice(6)
-	cosVal := math.Cos(float64(dice))
-	cosBig := new(big.Float).SetPrec(a.Value.Prec()).SetFloat64(cosVal)
+	prec := utils.Precision(vgs)
+	dice := utils.RollDice(vgs, 6)
+	cosVal, err := bigmath.Cos(new(big.Float).SetInt64(int64(dice)), prec)
+	if err != nil {
+		return err
+	}
 
-	a.Value.Mul(a.Value, cosBig)
+	expr := a.Expression()
+	a.SetFloat(new(big.Float).SetPrec(prec).Mul(a.Value, cosVal))
+	a.Expr = model.NewExpr(model.OpMul, expr, model.NewExpr(model.OpCos, diceExpr(dice)))
 
 	return nil
 }
@@ -207,11 +217,16 @@ func SINMOD(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	dice := utils.RollDice(6)
-	sinVal := math.Sin(float64(dice))
-	sinBig := new(big.Float).SetPrec(a.Value.Prec()).SetFloat64(sinVal)
+	prec := utils.Precision(vgs)
+	dice := utils.RollDice(vgs, 6)
+	sinVal, err := bigmath.Sin(new(big.Float).SetInt64(int64(dice)), prec)
+	if err != nil {
+		return err
+	}
 
-	a.Value.Mul(a.Value, sinBig)
+	expr := a.Expression()
+	a.SetFloat(new(big.Float).SetPrec(prec).Mul(a.Value, sinVal))
+	a.Expr = model.NewExpr(model.OpMul, expr, model.NewExpr(model.OpSin, diceExpr(dice)))
 
 	return nil
 }
@@ -225,11 +240,16 @@ func TANMOD(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	dice := utils.RollDice(6)
-	tanVal := math.Tan(float64(dice))
-	tanBig := new(big.Float).SetPrec(a.Value.Prec()).SetFloat64(tanVal)
+	prec := utils.Precision(vgs)
+	dice := utils.RollDice(vgs, 6)
+	tanVal, err := bigmath.Tan(new(big.Float).SetInt64(int64(dice)), prec)
+	if err != nil {
+		return err
+	}
 
-	a.Value.Mul(a.Value, tanBig)
+	expr := a.Expression()
+	a.SetFloat(new(big.Float).SetPrec(prec).Mul(a.Value, tanVal))
+	a.Expr = model.NewExpr(model.OpMul, expr, model.NewExpr(model.OpTan, diceExpr(dice)))
 
 	return nil
 }
@@ -247,9 +267,13 @@ func LOG10(vgs *model.GameState, card *model.Card) error {
 		return fmt.Errorf("cannot take a logarithm a negative number")
 	}
 
-	val, _ := a.Value.Float64()
-	logVal := math.Log10(val)
-	a.Value.SetPrec(a.Value.Prec()).SetFloat64(logVal)
+	logVal, err := bigmath.Log10(a.Value, utils.Precision(vgs))
+	if err != nil {
+		return err
+	}
+	expr := a.Expression()
+	a.SetFloat(logVal)
+	a.Expr = model.NewExpr(model.OpLog10, expr)
 
 	return nil
 }
@@ -263,9 +287,13 @@ func EXPONENTIAL(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 
-	val, _ := a.Value.Float64()
-	expVal := math.Exp(val)
-	a.Value.SetPrec(a.Value.Prec()).SetFloat64(expVal)
+	expVal, err := bigmath.Exp(a.Value, utils.Precision(vgs))
+	if err != nil {
+		return err
+	}
+	expr := a.Expression()
+	a.SetFloat(expVal)
+	a.Expr = model.NewExpr(model.OpExp, expr)
 
 	return nil
 }
@@ -283,9 +311,13 @@ func NATLOG(vgs *model.GameState, card *model.Card) error {
 		return fmt.Errorf("cannot take a logarithm a negative number")
 	}
 
-	val, _ := a.Value.Float64()
-	lnVal := math.Log(val)
-	a.Value.SetPrec(a.Value.Prec()).SetFloat64(lnVal)
+	lnVal, err := bigmath.Log(a.Value, utils.Precision(vgs))
+	if err != nil {
+		return err
+	}
+	expr := a.Expression()
+	a.SetFloat(lnVal)
+	a.Expr = model.NewExpr(model.OpLn, expr)
 
 	return nil
 }
@@ -298,15 +330,20 @@ func LOGORHYTHM(vgs *model.GameState, card *model.Card) error {
 	utils.CheckCardMark(vgs, attackerPlayer, attackerIndex)
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
-	dice := utils.RollDice(6)
+	dice := utils.RollDice(vgs, 6)
 
 	if a.Value.Sign() < 0 {
 		return fmt.Errorf("cannot take a logarithm a negative number")
 	}
 
-	val, _ := a.Value.Float64()
-	logVal := math.Log(val) / math.Log(float64(dice))
-	a.Value.SetPrec(a.Value.Prec()).SetFloat64(logVal)
+	base := new(big.Float).SetInt64(int64(dice))
+	logVal, err := bigmath.LogBase(a.Value, base, utils.Precision(vgs))
+	if err != nil {
+		return err
+	}
+	expr := a.Expression()
+	a.SetFloat(logVal)
+	a.Expr = model.NewExpr(model.OpLog, expr, diceExpr(dice))
 
 	return nil
 }
@@ -319,15 +356,19 @@ func ROOTBASE(vgs *model.GameState, card *model.Card) error {
 	utils.CheckCardMark(vgs, attackerPlayer, attackerIndex)
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
-	dice := utils.RollDice(6)
+	dice := utils.RollDice(vgs, 6)
 
 	if a.Value.Sign() < 0 {
 		return fmt.Errorf("cannot take a logarithm a negative number")
 	}
 
-	val, _ := a.Value.Float64()
-	result := math.Pow(val, 1.0/float64(dice))
-	a.Value.SetPrec(a.Value.Prec()).SetFloat64(result)
+	result, err := bigmath.Root(a.Value, int64(dice), utils.Precision(vgs))
+	if err != nil {
+		return err
+	}
+	expr := a.Expression()
+	a.SetFloat(result)
+	a.Expr = model.NewExpr(model.OpRoot, expr, diceExpr(dice))
 
 	return nil
 }
@@ -340,11 +381,26 @@ func EXPONENTBASE(vgs *model.GameState, card *model.Card) error {
 	utils.CheckCardMark(vgs, attackerPlayer, attackerIndex)
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
-	dice := utils.RollDice(6)
+	dice := utils.RollDice(vgs, 6)
+
+	expr := model.NewExpr(model.OpPow, a.Expression(), diceExpr(dice))
+
+	// an integer power of a fraction is still a fraction
+	if a.IsExact() {
+		exp := big.NewInt(int64(dice))
+		num := new(big.Int).Exp(a.Rat.Num(), exp, nil)
+		den := new(big.Int).Exp(a.Rat.Denom(), exp, nil)
+		a.SetRat(new(big.Rat).SetFrac(num, den))
+		a.Expr = expr
+		return nil
+	}
 
-	val, _ := a.Value.Float64()
-	result := math.Pow(val, float64(dice))
-	a.Value.SetPrec(a.Value.Prec()).SetFloat64(result)
+	result, err := bigmath.PowInt(a.Value, int64(dice), utils.Precision(vgs))
+	if err != nil {
+		return err
+	}
+	a.SetFloat(result)
+	a.Expr = expr
 
 	return nil
 }
@@ -361,15 +417,12 @@ func POLYNOMIAL1(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 	b := &vgs.Numbers[userPlayer][userIndex]
-	prec := a.Value.Prec()
-	d := new(big.Float).SetPrec(prec).SetFloat64(float64(utils.RollDice(6)))
-
-	term1 := new(big.Float).SetPrec(prec).Mul(a.Value, d)
+	d := model.IntNumber(int64(utils.RollDice(vgs, 6)), vgs.Settings.Exact, utils.Precision(vgs))
 
-	a.Value.Add(term1, b.Value)
+	a.Mul(&d)
+	a.Add(b)
 
-	b.Value = big.NewFloat(0)
-	b.Mark = "n"
+	b.Clear()
 
 	return nil
 }
@@ -390,21 +443,16 @@ func POLYNOMIAL2(vgs *model.GameState, card *model.Card) error {
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 	b := &vgs.Numbers[userPlayer][userIndex]
 	c := &vgs.Numbers[userPlayer2][userIndex2]
-	prec := a.Value.Prec()
-	d := new(big.Float).SetPrec(prec).SetFloat64(float64(utils.RollDice(6)))
-	d2 := new(big.Float).SetPrec(prec).Mul(d, d)
-
-	term1 := new(big.Float).SetPrec(prec).Mul(a.Value, d2)
-	term2 := new(big.Float).SetPrec(prec).Mul(b.Value, d)
+	d := model.IntNumber(int64(utils.RollDice(vgs, 6)), vgs.Settings.Exact, utils.Precision(vgs))
 
-	a.Value.Add(term1, term2)
-	a.Value.Add(a.Value, c.Value)
+	// (a*d + b)*d + c
+	a.Mul(&d)
+	a.Add(b)
+	a.Mul(&d)
+	a.Add(c)
 
-	b.Value = big.NewFloat(0)
-	b.Mark = "n"
-
-	c.Value = big.NewFloat(0)
-	c.Mark = "n"
+	b.Clear()
+	c.Clear()
 
 	return nil
 }
@@ -425,22 +473,15 @@ func SIGMANOTATION(vgs *model.GameState, card *model.Card) error {
 		return fmt.Errorf("no numbers to sum")
 	}
 
-	prec := numbers[dest].Value.Prec()
-	sum := new(big.Float).SetPrec(prec).SetFloat64(0)
+	sum := &numbers[dest]
 
 	for i := range numbers {
-		if numbers[i].Mark != "n" {
-			sum.Add(sum, numbers[i].Value)
-
-			if i != dest {
-				numbers[i].Value = big.NewFloat(0)
-				numbers[i].Mark = "n"
-			}
+		if numbers[i].Mark != "n" && i != dest {
+			sum.Add(&numbers[i])
+			numbers[i].Clear()
 		}
 	}
 
-	numbers[dest].Value = sum
-
 	// Write back
 	vgs.Numbers[player] = numbers
 
@@ -463,22 +504,15 @@ func PRODUCTNOTATION(vgs *model.GameState, card *model.Card) error {
 		return fmt.Errorf("no numbers to multiply")
 	}
 
-	prec := numbers[dest].Value.Prec()
-	product := new(big.Float).SetPrec(prec).SetFloat64(1)
+	product := &numbers[dest]
 
 	for i := range numbers {
-		if numbers[i].Mark != "n" {
-			product.Mul(product, numbers[i].Value)
-
-			if i != dest {
-				numbers[i].Value = big.NewFloat(0)
-				numbers[i].Mark = "n"
-			}
+		if numbers[i].Mark != "n" && i != dest {
+			product.Mul(&numbers[i])
+			numbers[i].Clear()
 		}
 	}
 
-	numbers[dest].Value = product
-
 	// Write back
 	vgs.Numbers[player] = numbers
 
@@ -0,0 +1,55 @@
+package cards
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Applies a card to the state, card.Inputs are already validated against the signature
+type Func func(vgs *model.GameState, card *model.Card) error
+
+// Code behind the Method column of the csv
+type Method struct {
+	Name   string // value of the Method column
+	Type   string // Function, Theorem or Constant, used when a row leaves Type empty
+	Inputs string // InputsReq the function reads, see model.Card
+	Apply  Func
+}
+
+var methods = map[string]Method{}
+
+// Registers a card method, call it from init. Packages outside this module can
+// add cards this way and list them in a csv with the same Method and InputsReq.
+// Panics on a duplicate name or an invalid signature.
+func Register(m Method) {
+	if m.Name == "" || m.Apply == nil {
+		panic("cards: Register needs a name and a function")
+	}
+	if _, ok := methods[m.Name]; ok {
+		panic(fmt.Sprintf("cards: method %s registered twice", m.Name))
+	}
+	if _, err := model.InputSpecs(m.Inputs); err != nil {
+		panic(fmt.Sprintf("cards: method %s: %v", m.Name, err))
+	}
+	methods[m.Name] = m
+}
+
+// Returns the registered method
+func Lookup(name string) (Method, bool) {
+	m, ok := methods[name]
+	return m, ok
+}
+
+// Every registered method, sorted by name
+func Methods() []Method {
+	list := make([]Method, 0, len(methods))
+	for _, m := range methods {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
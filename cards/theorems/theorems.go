@@ -43,8 +43,9 @@ func ELEMENTDISTRIBUTIVE(vgs *model.GameState, card *model.Card) error {
 		if i == attackedPlayer {
 			continue
 		}
-		val := new(big.Float).SetPrec(a.Value.Prec()).Set(a.Value)
-		constants.AddConstant(vgs, i, val, "")
+		val := a.Clone()
+		val.Mark = ""
+		constants.AddConstant(vgs, i, val)
 	}
 
 	return nil
@@ -63,14 +64,7 @@ func ELEMENTCOMMUTATIVE(vgs *model.GameState, card *model.Card) error {
 	a := &vgs.Numbers[player1][index1]
 	b := &vgs.Numbers[player2][index2]
 
-	tmpVal := new(big.Float).SetPrec(a.Value.Prec()).Set(a.Value)
-	tmpMark := a.Mark
-
-	a.Value = new(big.Float).SetPrec(a.Value.Prec()).Set(b.Value)
-	a.Mark = b.Mark
-
-	b.Value = tmpVal
-	b.Mark = tmpMark
+	*a, *b = b.Clone(), a.Clone()
 
 	return nil
 }
@@ -87,15 +81,23 @@ func PYTHAGOREANTHEOREM(vgs *model.GameState, card *model.Card) error {
 
 	a := &vgs.Numbers[attackerPlayer][attackerIndex]
 	b := &vgs.Numbers[userPlayer][userIndex]
-	prec := a.Value.Prec()
+	prec := utils.Precision(vgs)
+
+	two := model.NumExpr("2")
+	expr := model.NewExpr(model.OpSqrt, model.NewExpr(model.OpAdd,
+		model.NewExpr(model.OpPow, a.Expression(), two),
+		model.NewExpr(model.OpPow, b.Expression(), two),
+	))
 
-	a2 := new(big.Float).SetPrec(prec).Mul(a.Value, a.Value)
-	b2 := new(big.Float).SetPrec(prec).Mul(b.Value, b.Value)
-	sum := new(big.Float).SetPrec(prec).Add(a2, b2)
-	a.Value.SetPrec(prec).Sqrt(sum)
+	sum := a.Clone()
+	sum.Mul(a)
+	b2 := b.Clone()
+	b2.Mul(b)
+	sum.Add(&b2)
+	a.SetFloat(new(big.Float).SetPrec(prec).Sqrt(sum.Value))
+	a.Expr = expr
 
-	b.Value = big.NewFloat(0)
-	b.Mark = "n"
+	b.Clear()
 
 	return nil
 }
@@ -117,13 +119,8 @@ func PASCALTRIANGLE(vgs *model.GameState, card *model.Card) error {
 
 	// collapse island using Pascal rule
 	for i := L + 1; i <= R; i++ {
-		prec := nums[L].Value.Prec()
-		nums[L].Value = new(big.Float).
-			SetPrec(prec).
-			Add(nums[L].Value, nums[i].Value)
-
-		nums[i].Value = big.NewFloat(0)
-		nums[i].Mark = "n"
+		nums[L].Add(&nums[i])
+		nums[i].Clear()
 	}
 
 	vgs.Numbers[player] = nums
@@ -141,7 +138,7 @@ func FUNDAMENTALTHEOREMOFARITHMETIC(vgs *model.GameState, card *model.Card) erro
 	num := &vgs.Numbers[player][index]
 
 	// must be integer
-	intVal, ok := utils.FloatToIntExact(num.Value)
+	intVal, ok := num.Int()
 	if !ok || intVal.Cmp(big.NewInt(1)) <= 0 {
 		return fmt.Errorf("number must be integer > 1")
 	}
@@ -153,8 +150,7 @@ func FUNDAMENTALTHEOREMOFARITHMETIC(vgs *model.GameState, card *model.Card) erro
 		err := constants.AddConstant(
 			vgs,
 			player,
-			new(big.Float).SetInt(f),
-			"",
+			model.BigIntNumber(f, vgs.Settings.Exact, utils.Precision(vgs)),
 		)
 		if err != nil {
 			return err
@@ -162,8 +158,24 @@ func FUNDAMENTALTHEOREMOFARITHMETIC(vgs *model.GameState, card *model.Card) erro
 	}
 
 	// consume original
-	num.Value = big.NewFloat(0)
-	num.Mark = "n"
+	num.Clear()
+
+	return nil
+}
+
+// Input: c
+func COUNTEREXAMPLE(vgs *model.GameState, card *model.Card) error {
+	target := card.Inputs[0]
+
+	// off the queue and into the used pile, the queue skips it
+	for i, queued := range vgs.Queue {
+		if queued == target {
+			vgs.Queue = append(vgs.Queue[:i:i], vgs.Queue[i+1:]...)
+			break
+		}
+	}
+	vgs.Cards[target].Owner = -2
+	vgs.Cards[target].Inputs = nil
 
 	return nil
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/umarbektokyo/matetra-engine/api"
+
+	"github.com/gorilla/websocket"
+)
+
+// Reconnect backoff, doubled after every failed attempt
+const (
+	reconnectDelay    = time.Second
+	reconnectMaxDelay = 30 * time.Second
+	reconnectAttempts = 10
+)
+
+// Websocket connection to the server that can be swapped out after a drop
+type Connection struct {
+	url  string
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func Dial(url string) (*Connection, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Connection{url: url, conn: conn}, nil
+}
+
+func (c *Connection) current() *websocket.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
+func (c *Connection) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+// Only read from one goroutine at a time
+func (c *Connection) ReadJSON(v interface{}) error {
+	return c.current().ReadJSON(v)
+}
+
+func (c *Connection) Close() error {
+	return c.current().Close()
+}
+
+// Dials again with backoff and resumes the session with the token from the
+// join, the server answers with RESUMED and the current state
+func (c *Connection) Reconnect(token string) error {
+	c.current().Close()
+
+	delay := reconnectDelay
+	for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+		fmt.Printf("\n[INFO] Connection lost, reconnecting in %s (attempt %d/%d)...\n", delay, attempt, reconnectAttempts)
+		time.Sleep(delay)
+		delay = min(delay*2, reconnectMaxDelay)
+
+		conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+		if err != nil {
+			continue
+		}
+		resume := api.Message{Type: "RESUME", Payload: api.ResumePayload{Token: token}}
+		if err := conn.WriteJSON(resume); err != nil {
+			conn.Close()
+			continue
+		}
+
+		c.mu.Lock()
+		c.conn = conn
+		c.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("could not reach the server after %d attempts", reconnectAttempts)
+}
@@ -21,6 +21,7 @@ import (
 var CurrentGameState model.GameState
 var PlayerID int = -1
 var PlayerName string
+var SessionToken string // resumes the seat after a reconnect
 var Banner string
 
 func main() {
@@ -49,7 +50,7 @@ func main() {
 
 	fmt.Printf("Attempting to connect to server at %s...\n", serverAddr)
 
-	c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	c, err := Dial(u.String())
 	if err != nil {
 		log.Fatalf("error: could not connect to server at %s. Is the server still running? %v", u.String(), err)
 	}
@@ -72,7 +73,7 @@ func main() {
 // REGISTRATION AND INITIAL STATE SETUP
 // ----------------------------------------------------------------------
 
-func registerPlayer(c *websocket.Conn) error {
+func registerPlayer(c *Connection) error {
 	reader := bufio.NewReader(os.Stdin)
 
 	// get username
@@ -93,14 +94,10 @@ func registerPlayer(c *websocket.Conn) error {
 	fmt.Printf("Hashed password (SHA256): %s...\n", passwordHash[:8])
 	PlayerName = username // Set player name globally
 
-	// construct the ADD_PLAYER message
-	payload := api.PlayerPayload{
-		Name: username,
-		Hash: passwordHash,
-	}
-	addPlayerMsg := api.Message{
-		Type:    "ADD_PLAYER",
-		Payload: payload,
+	// pick a game hosted by the server or create a new one
+	addPlayerMsg, err := chooseGame(c, reader, username, passwordHash)
+	if err != nil {
+		return err
 	}
 
 	fmt.Println("Registering player...")
@@ -117,7 +114,18 @@ func registerPlayer(c *websocket.Conn) error {
 		}
 
 		if response.Type == "PLAYER_ADDED" {
-			fmt.Printf("Success: player @%s has been added to the game!\n", username)
+			var added api.PlayerAddedPayload
+			payloadBytes, _ := json.Marshal(response.Payload)
+			if err := json.Unmarshal(payloadBytes, &added); err != nil {
+				return fmt.Errorf("registration failed: invalid reply")
+			}
+			PlayerID = added.PlayerID
+			SessionToken = added.Token
+			if added.Rejoined {
+				fmt.Printf("Welcome back @%s, you have your seat again!\n", username)
+			} else {
+				fmt.Printf("Success: player @%s has been added to the game!\n", username)
+			}
 			break
 		} else if response.Type == "ERROR" {
 			errorPayloadBytes, err := json.Marshal(response.Payload)
@@ -168,21 +176,62 @@ func registerPlayer(c *websocket.Conn) error {
 
 	CurrentGameState = gameState
 
-	// Find PlayerID from the received state
-	for i, p := range gameState.Players {
-		if p.Name == username {
-			PlayerID = i
+	// Display the initial state before starting the command loop
+	displayGameState(CurrentGameState)
+
+	return nil
+}
+
+// Lists the games on the server and builds the JOIN_GAME or CREATE_GAME message
+func chooseGame(c *Connection, reader *bufio.Reader, username, passwordHash string) (api.Message, error) {
+	if err := c.WriteJSON(api.Message{Type: "LIST_GAMES"}); err != nil {
+		return api.Message{}, fmt.Errorf("error requesting the game list: %v", err)
+	}
+
+	var games []api.GameInfo
+	for {
+		var response api.Message
+		if err := c.ReadJSON(&response); err != nil {
+			return api.Message{}, fmt.Errorf("error reading the game list: %v", err)
+		}
+		if response.Type == "GAMES" {
+			payloadBytes, _ := json.Marshal(response.Payload)
+			if err := json.Unmarshal(payloadBytes, &games); err != nil {
+				return api.Message{}, fmt.Errorf("invalid game list: %v", err)
+			}
 			break
 		}
 	}
-	if PlayerID == -1 {
-		return fmt.Errorf("could not find registered player ID in initial state")
+
+	displayGames(games)
+	fmt.Print("Game ID to join (empty to create a new game): ")
+	gameID, _ := reader.ReadString('\n')
+	gameID = strings.TrimSpace(gameID)
+
+	if gameID != "" {
+		return api.Message{
+			Type:    "JOIN_GAME",
+			Payload: api.JoinGamePayload{GameID: gameID, Name: username, Hash: passwordHash},
+		}, nil
 	}
 
-	// Display the initial state before starting the command loop
-	displayGameState(CurrentGameState)
+	fmt.Print("Title of the new game: ")
+	title, _ := reader.ReadString('\n')
+	return api.Message{
+		Type:    "CREATE_GAME",
+		Payload: api.CreateGamePayload{Title: strings.TrimSpace(title), Name: username, Hash: passwordHash},
+	}, nil
+}
 
-	return nil
+func displayGames(games []api.GameInfo) {
+	fmt.Println("\n--- GAMES ON THIS SERVER ---")
+	if len(games) == 0 {
+		fmt.Println("  (No games yet)")
+	}
+	for _, game := range games {
+		fmt.Printf("  [%s] %s (%s, turn %d) players: %s\n",
+			game.GameID, game.Title, game.Phase, game.Turn, strings.Join(game.Players, ", "))
+	}
 }
 
 // ----------------------------------------------------------------------
@@ -192,21 +241,24 @@ func registerPlayer(c *websocket.Conn) error {
 func displayGameState(gs model.GameState) {
 	fmt.Print("\033[H\033[2J") // Clear terminal screen
 
-	// Ensure PlayerID is set (should be from registerPlayer, but safe check)
-	if PlayerID == -1 {
-		for i, p := range gs.Players {
-			if p.Name == PlayerName {
-				PlayerID = i
-				break
-			}
-		}
-	}
-
 	if len(gs.Players) == 0 || gs.Turn == -1 {
 		fmt.Println("Waiting for game to start...")
 		return
 	}
 
+	if gs.Phase == model.PhaseLobby {
+		displayLobby(gs)
+		return
+	}
+
+	if gs.Phase == model.PhaseFinished {
+		winners := make([]string, len(gs.Winners))
+		for i, w := range gs.Winners {
+			winners[i] = "@" + gs.Players[w].Name
+		}
+		Banner = fmt.Sprintf("🏆 GAME OVER! Winner(s): %s", strings.Join(winners, ", "))
+	}
+
 	// Determine current player index safely
 	currentPlayerIndex := gs.Turn % len(gs.Players)
 
@@ -215,6 +267,14 @@ func displayGameState(gs model.GameState) {
 	fmt.Printf(" GAME: %s | TURN: %d | CURRENT PLAYER: @%s (ID: %d)\n", gs.GameID, gs.Turn, gs.Players[currentPlayerIndex].Name, currentPlayerIndex)
 	fmt.Println("=====================================================================")
 
+	// other players' cards are face down, only how many they hold is known
+	handCounts := make([]int, len(gs.Players))
+	for _, card := range gs.Cards {
+		if card.Owner >= 0 && card.Owner < len(handCounts) {
+			handCounts[card.Owner]++
+		}
+	}
+
 	// 1. Display Player Numbers
 	fmt.Println("\n--- PLAYER NUMBERS ---")
 	for i, p := range gs.Players {
@@ -230,16 +290,18 @@ func displayGameState(gs model.GameState) {
 			marker = "🎯" // Turn player
 		}
 
-		numberStrings := make([]string, 5)
+		var numberStrings []string
 		if i < len(gs.Numbers) {
+			numberStrings = make([]string, len(gs.Numbers[i]))
 			for j, num := range gs.Numbers[i] {
 				// Format: [Index:ValueMark]
-				displayValue := num.Value.Text('g', 10)
+				// exact numbers show up as fractions
+				displayValue := num.String()
 				numberStrings[j] = fmt.Sprintf("[%d:%s%s]", j, displayValue, num.Mark)
 			}
 		}
 
-		fmt.Printf("%s %s @%s (ID: %d): %s\n", marker, doneStatus, p.Name, i, strings.Join(numberStrings, " | "))
+		fmt.Printf("%s %s @%s (ID: %d, %d cards): %s\n", marker, doneStatus, p.Name, i, handCounts[i], strings.Join(numberStrings, " | "))
 	}
 
 	// 2. Display Player Hand
@@ -250,7 +312,18 @@ func displayGameState(gs model.GameState) {
 			handCount++
 			// Find the required input string from the card
 			inputsReq := card.InputsReq
-			fmt.Printf("  [C:%d] %s (Req: %s) -> %s\n", i, card.Name, inputsReq, card.Description)
+			if len(card.Schema) > 0 {
+				labels := make([]string, len(card.Schema))
+				for j, spec := range card.Schema {
+					labels[j] = spec.Label
+				}
+				inputsReq = strings.Join(labels, ", ")
+			}
+			description := card.Description
+			if description == "" && card.Formula != "" {
+				description = card.Formula
+			}
+			fmt.Printf("  [C:%d] %s (Req: %s, P%d) -> %s\n", i, card.Name, inputsReq, card.Precedence, description)
 		}
 	}
 	if handCount == 0 {
@@ -263,37 +336,123 @@ func displayGameState(gs model.GameState) {
 		queueDetails := make([]string, len(gs.Queue))
 		for i, cardIndex := range gs.Queue {
 			// Find the card name
-			cardName := "Unknown Card"
+			cardName, cardID := "Unknown Card", ""
 			if cardIndex >= 0 && cardIndex < len(gs.Cards) {
-				cardName = gs.Cards[cardIndex].Name
+				cardName, cardID = gs.Cards[cardIndex].Name, gs.Cards[cardIndex].ID
 			}
 			// Display the card and the inputs (if available, they should be in the state)
 			var inputs string
 			if cardIndex < len(gs.Cards) && len(gs.Cards[cardIndex].Inputs) > 0 {
 				inputs = fmt.Sprintf("(Inputs: %v)", gs.Cards[cardIndex].Inputs)
 			}
-			queueDetails[i] = fmt.Sprintf("%s %s", cardName, inputs)
+			queueDetails[i] = fmt.Sprintf("[C:%d %s] %s %s", cardIndex, cardID, cardName, inputs)
 		}
 		fmt.Printf("  %s\n", strings.Join(queueDetails, " -> "))
 	} else {
 		fmt.Println("  (Queue is empty)")
 	}
 
+	// 4. Display how the last queue resolved (higher precedence first)
+	if len(gs.Resolved) > 0 {
+		fmt.Println("\n--- LAST RESOLUTION ORDER ---")
+		resolved := make([]string, len(gs.Resolved))
+		for i, r := range gs.Resolved {
+			if r.Card >= 0 && r.Card < len(gs.Cards) {
+				resolved[i] = fmt.Sprintf("%s (P%d)", gs.Cards[r.Card].Name, gs.Cards[r.Card].Precedence)
+			}
+			if r.Outcome != model.OutcomeApplied {
+				resolved[i] += fmt.Sprintf(" [%s]", r.Outcome)
+			}
+		}
+		fmt.Printf("  %s\n", strings.Join(resolved, " -> "))
+	}
+
 	fmt.Println("---------------------------------------------------------------------")
 	fmt.Println("\n💡 COMMANDS:")
 	fmt.Println("  apply(cardIndex, [inputs...], permanent)  - Play a card (permanent=1, preview=0)")
+	fmt.Println("                                              a card input (c) is the [C:n] or the ID of a queued card")
+	fmt.Println("  unplay(cardIndex)                         - Take back a queued card")
 	fmt.Println("  roll / dice                               - Roll the dice")
 	fmt.Println("  turnend                                   - End your turn")
+	fmt.Println("  history(player, slot[, latex])            - Show how a number was derived")
 	fmt.Println("  state                                     - Refresh board")
 	fmt.Println("  help                                      - Show help")
 	fmt.Println("  exit                                      - Quit")
 }
 
+// Prints the expression a number was derived from, as text or LaTeX
+func displayHistory(gs model.GameState, player int, slot int, latex bool) {
+	if player < 0 || player >= len(gs.Numbers) || slot < 0 || slot >= len(gs.Numbers[player]) {
+		fmt.Println("No such number.")
+		return
+	}
+	num := gs.Numbers[player][slot]
+	if num.Mark == "n" {
+		fmt.Println("The slot is empty.")
+		return
+	}
+
+	if latex {
+		fmt.Printf("%s = %s\n", num.Expression().LaTeX(), num.String())
+		return
+	}
+	fmt.Printf("%s = %s\n", num.Expression().String(), num.String())
+}
+
+func displayLobby(gs model.GameState) {
+	fmt.Println(Banner)
+	fmt.Println("\n=====================================================================")
+	fmt.Printf(" GAME: %s | LOBBY | HOST: @%s\n", gs.GameID, gs.Players[0].Name)
+	fmt.Println("=====================================================================")
+
+	fmt.Println("\n--- PLAYERS ---")
+	for i, p := range gs.Players {
+		readyStatus := "⏳ WAITING"
+		if i < len(gs.Ready) && gs.Ready[i] {
+			readyStatus = "✅ READY"
+		}
+
+		marker := "  "
+		if i == PlayerID {
+			marker = ">>"
+		}
+		fmt.Printf("%s %s @%s (ID: %d)\n", marker, readyStatus, p.Name, i)
+	}
+
+	fmt.Println("\n--- SETTINGS ---")
+	settingsBytes, _ := json.Marshal(gs.Settings)
+	fmt.Printf("  %s\n", settingsBytes)
+
+	fmt.Println("---------------------------------------------------------------------")
+	fmt.Println("\n💡 COMMANDS:")
+	fmt.Println("  ready / unready                           - Toggle your ready status")
+	fmt.Println("  packs                                     - List the card packs")
+	fmt.Println("  bot(greedy) / bot(random, name)           - Fill a seat with a bot")
+	fmt.Println("  settings {json}                           - Change settings (host only)")
+	fmt.Println("      ex: settings {\"Packs\":[\"Core0\"],\"HandSize\":4,\"RowSize\":3}")
+	fmt.Println("  start                                     - Start the game (host only)")
+	fmt.Println("  state                                     - Refresh lobby")
+	fmt.Println("  exit                                      - Quit")
+}
+
+func displayStandings(result api.GameOverPayload) {
+	fmt.Println("\n=====================================================================")
+	fmt.Println(" GAME OVER")
+	fmt.Println("=====================================================================")
+	for _, s := range result.Standings {
+		marker := "  "
+		if s.Player == PlayerID {
+			marker = ">>"
+		}
+		fmt.Printf("%s #%d @%s (ID: %d): %s\n", marker, s.Rank, s.Name, s.Player, s.Score.Text('g', 10))
+	}
+}
+
 // ----------------------------------------------------------------------
 // MESSAGE LISTENER (Async)
 // ----------------------------------------------------------------------
 
-func listenForUpdates(c *websocket.Conn) {
+func listenForUpdates(c *Connection) {
 	for {
 		var msg api.Message
 		if err := c.ReadJSON(&msg); err != nil {
@@ -301,7 +460,10 @@ func listenForUpdates(c *websocket.Conn) {
 				fmt.Println("\nServer connection closed.")
 				os.Exit(0)
 			}
-			log.Printf("Error reading message: %v", err)
+			// the seat is kept on the server, get it back with the session token
+			if err := c.Reconnect(SessionToken); err != nil {
+				log.Fatalf("\n[ERROR] %v", err)
+			}
 			continue
 		}
 
@@ -329,12 +491,44 @@ func listenForUpdates(c *websocket.Conn) {
 			fmt.Printf("\n%s %s\n", prefix, reply.Message)
 			fmt.Print(">>> ")
 
+		case "RESUMED":
+			fmt.Println("\n[INFO] Reconnected, welcome back!")
 		case "ERROR":
 			errorPayloadBytes, _ := json.Marshal(msg.Payload)
 			var errorData map[string]string
 			json.Unmarshal(errorPayloadBytes, &errorData)
 			fmt.Printf("\n[SERVER ERROR]: %s\n", errorData["message"])
 			fmt.Print(">>> ")
+		case "GAME_OVER":
+			var result api.GameOverPayload
+			payloadBytes, _ := json.Marshal(msg.Payload)
+			if err := json.Unmarshal(payloadBytes, &result); err != nil {
+				log.Printf("Error unmarshalling GameOverPayload: %v", err)
+				continue
+			}
+			displayStandings(result)
+			fmt.Print(">>> ")
+		case "GAMES":
+			var games []api.GameInfo
+			payloadBytes, _ := json.Marshal(msg.Payload)
+			if err := json.Unmarshal(payloadBytes, &games); err != nil {
+				log.Printf("Error unmarshalling games: %v", err)
+				continue
+			}
+			displayGames(games)
+			fmt.Print(">>> ")
+		case "PACKS":
+			var packs []model.Pack
+			payloadBytes, _ := json.Marshal(msg.Payload)
+			if err := json.Unmarshal(payloadBytes, &packs); err != nil {
+				log.Printf("Error unmarshalling packs: %v", err)
+				continue
+			}
+			fmt.Println("\n--- CARD PACKS ---")
+			for _, pack := range packs {
+				fmt.Printf("  %s (%d cards): %s\n", pack.Name, pack.Size, strings.Join(pack.Cards, ", "))
+			}
+			fmt.Print(">>> ")
 		case "STATE_UPDATE":
 			// FIX: Handle global state updates (e.g. when other players join or turn changes)
 			statePayloadBytes, err := json.Marshal(msg.Payload)
@@ -361,7 +555,7 @@ func listenForUpdates(c *websocket.Conn) {
 // COMMAND INTERFACE (Blocking)
 // ----------------------------------------------------------------------
 
-func commandLoop(c *websocket.Conn) {
+func commandLoop(c *Connection) {
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		// Ensure the command prompt appears clearly after the state
@@ -399,19 +593,15 @@ func commandLoop(c *websocket.Conn) {
 			}
 
 			// Extract inputs and 'permanent' flag
-			var inputs []int
-			hasError := false
+			// a card input can also be the ID of the card
+			var inputs []api.PlayInput
 			for i := 2; i < len(parts)-1; i++ {
 				inputVal, err := strconv.Atoi(parts[i])
 				if err != nil {
-					fmt.Printf("Invalid input value '%s' at position %d.\n", parts[i], i-1)
-					hasError = true
-					break
+					inputs = append(inputs, api.PlayInput{CardID: parts[i]})
+					continue
 				}
-				inputs = append(inputs, inputVal)
-			}
-			if hasError {
-				continue // Error already reported
+				inputs = append(inputs, api.PlayInput{Value: inputVal})
 			}
 
 			// Parse permanent flag
@@ -425,12 +615,72 @@ func commandLoop(c *websocket.Conn) {
 
 			sendPlayCard(c, cardIndex, inputs, permanent)
 
+		case "unplay":
+			if len(parts) != 2 {
+				fmt.Println("Usage: unplay(cardIndex)")
+				continue
+			}
+			cardIndex, err := strconv.Atoi(parts[1])
+			if err != nil {
+				fmt.Println("Invalid card index (must be integer).")
+				continue
+			}
+			sendMessage(c, "UNPLAY_CARD", api.CardUnplayPayload{CardIndex: cardIndex})
+
 		case "turnend":
 			sendTurnEnd(c)
 
 		case "roll", "dice":
 			sendDiceRoll(c)
 
+		case "ready", "unready":
+			sendReady(c, command == "ready")
+
+		case "start":
+			sendSimple(c, "START_GAME")
+
+		case "packs":
+			sendSimple(c, "LIST_PACKS")
+
+		case "games":
+			sendSimple(c, "LIST_GAMES")
+
+		case "bot":
+			// Usage: bot(greedy) or bot(random, name)
+			payload := api.AddBotPayload{}
+			if len(parts) > 1 {
+				payload.Strategy = strings.ToLower(parts[1])
+			}
+			if len(parts) > 2 {
+				payload.Name = parts[2]
+			}
+			sendMessage(c, "ADD_BOT", payload)
+
+		case "settings":
+			// Usage: settings {"Victory":[{"Type":"TARGET","Target":100}]}
+			raw := strings.TrimSpace(strings.TrimPrefix(input, parts[0]))
+			var settings map[string]interface{}
+			if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+				fmt.Println("Usage: settings {\"Victory\":[{\"Type\":\"TARGET\",\"Target\":100}]}")
+				continue
+			}
+			sendMessage(c, "UPDATE_SETTINGS", settings)
+
+		case "history":
+			// Usage: history(player, slot) or history(player, slot, latex)
+			if len(parts) < 3 {
+				fmt.Println("Usage: history(player, slot) or history(player, slot, latex)")
+				continue
+			}
+			player, err1 := strconv.Atoi(parts[1])
+			slot, err2 := strconv.Atoi(parts[2])
+			if err1 != nil || err2 != nil {
+				fmt.Println("Invalid player or slot (must be integers).")
+				continue
+			}
+			latex := len(parts) > 3 && strings.ToLower(parts[3]) == "latex"
+			displayHistory(CurrentGameState, player, slot, latex)
+
 		case "exit", "quit":
 			fmt.Println("Exiting client.")
 			return
@@ -444,8 +694,16 @@ func commandLoop(c *websocket.Conn) {
 		case "help":
 			fmt.Println("\nAvailable Commands:")
 			fmt.Println("  apply(C, I1..., P) : Play card")
+			fmt.Println("  unplay(C)          : Take back a queued card")
 			fmt.Println("  dice               : Roll dice")
+			fmt.Println("  ready / unready    : Lobby ready-check")
+			fmt.Println("  packs              : List card packs")
+			fmt.Println("  games              : List the games on the server")
+			fmt.Println("  bot(S[, name])     : Seat a random or greedy bot")
+			fmt.Println("  settings {json}    : Change settings")
+			fmt.Println("  start              : Start the game")
 			fmt.Println("  turnend            : End turn")
+			fmt.Println("  history(P, S[, latex]) : How a number was derived")
 			fmt.Println("  state              : Refresh")
 			fmt.Println("  exit               : Quit")
 
@@ -459,7 +717,7 @@ func commandLoop(c *websocket.Conn) {
 // COMMAND SENDERS
 // ----------------------------------------------------------------------
 
-func sendPlayCard(c *websocket.Conn, cardIndex int, inputs []int, permanent bool) {
+func sendPlayCard(c *Connection, cardIndex int, inputs []api.PlayInput, permanent bool) {
 	if PlayerID == -1 {
 		fmt.Println("[ERROR] Player ID not yet established. Cannot move.")
 		return
@@ -484,7 +742,7 @@ func sendPlayCard(c *websocket.Conn, cardIndex int, inputs []int, permanent bool
 	}
 }
 
-func sendTurnEnd(c *websocket.Conn) {
+func sendTurnEnd(c *Connection) {
 	if PlayerID == -1 {
 		fmt.Println("[ERROR] Player ID not yet established. Cannot end turn.")
 		return
@@ -502,7 +760,7 @@ func sendTurnEnd(c *websocket.Conn) {
 	fmt.Println("Sent turn end request. Waiting for update...")
 }
 
-func sendDiceRoll(c *websocket.Conn) {
+func sendDiceRoll(c *Connection) {
 	if PlayerID == -1 {
 		fmt.Println("[ERROR] Player ID not established.")
 		return
@@ -517,3 +775,27 @@ func sendDiceRoll(c *websocket.Conn) {
 		log.Printf("Error sending ROLL_DICE: %v", err)
 	}
 }
+
+func sendReady(c *Connection, ready bool) {
+	sendMessage(c, "READY", api.ReadyPayload{Ready: ready})
+}
+
+func sendSimple(c *Connection, msgType string) {
+	sendMessage(c, msgType, nil)
+}
+
+func sendMessage(c *Connection, msgType string, payload interface{}) {
+	if PlayerID == -1 {
+		fmt.Println("[ERROR] Player ID not established.")
+		return
+	}
+
+	msg := api.Message{
+		Type:    msgType,
+		Payload: payload,
+	}
+
+	if err := c.WriteJSON(msg); err != nil {
+		log.Printf("Error sending %s: %v", msgType, err)
+	}
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/umarbektokyo/matetra-engine/api"
+	"github.com/umarbektokyo/matetra-engine/cards"
 	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
 	"github.com/umarbektokyo/matetra-engine/utils"
 )
 
@@ -19,27 +24,130 @@ func main() {
 
 	switch cmd[1] {
 	case "start":
+		flags := flag.NewFlagSet("start", flag.ExitOnError)
+		seed := flags.Int64("seed", time.Now().UnixNano(), "seed for dice rolls and deck draws (default: current time)")
+		journalPath := flags.String("journal", "", "append every game event to a JSONL file per game, game.jsonl becomes game.<game id>.jsonl")
+		gameSettings := settingsFlags(flags)
+		deckPath := deckFlag(flags)
+		savePath := flags.String("save", "", "save every game to this file periodically and on shutdown, resume it with `matetra-server resume`")
+		autosave := flags.Duration("autosave", time.Minute, "how often to save the games with --save (0 saves on shutdown only)")
+		flags.Parse(cmd[2:])
+
 		title := "Wonderful Game"
-		if len(cmd) > 2 {
-			title = cmd[2]
+		if flags.NArg() > 0 {
+			title = flags.Arg(0)
 		}
 		utils.MatetraSplash()
-		game := engine.New(title)
-		log.Printf("loading card deck...")
-		game.LoadCards()
-		log.Printf("deck loaded with %d cards", len(game.State.Cards))
+		loadDeck(*deckPath)
+
+		// every game hosted by the server starts with these settings
+		settings := gameSettings()
+		if len(settings.Packs) > 0 {
+			log.Printf("deck will be built from packs: %s", strings.Join(settings.Packs, ","))
+		}
 
-		apiServer := api.New(game)
-		apiServer.Start()
+		apiServer := api.New(settings)
+		apiServer.SetJournal(*journalPath)
+		if _, err := apiServer.CreateGame(title, *seed); err != nil {
+			log.Fatalf("failed to create the game: %v", err)
+		}
+
+		serve(apiServer, *savePath, *autosave)
+	case "resume":
+		resumeServer(cmd[2:])
+	case "packs":
+		flags := flag.NewFlagSet("packs", flag.ExitOnError)
+		deckPath := deckFlag(flags)
+		flags.Parse(cmd[2:])
+		loadDeck(*deckPath)
+		listPacks()
+	case "simulate":
+		simulate(cmd[2:])
+	case "replay":
+		flags := flag.NewFlagSet("replay", flag.ExitOnError)
+		deckPath := deckFlag(flags)
+		flags.Parse(cmd[2:])
+		if flags.NArg() < 1 {
+			fmt.Println("usage: matetra-server replay [--cards <file>] <journal-file>")
+			return
+		}
+		loadDeck(*deckPath)
+		replayGame(flags.Arg(0))
 	default:
 		fmt.Println(cmd[1] + " not recognised.")
 		clientSplash()
 	}
 }
 
+// Registers the flags shared by every command that sets up games, the
+// returned function reads them back once the flags are parsed
+func settingsFlags(flags *flag.FlagSet) func() model.Settings {
+	packs := flags.String("packs", "", "comma separated packs to build the deck from (default: every pack)")
+	handSize := flags.Int("hand-size", engine.DefaultHandSize, "cards in every player's hand (4..10)")
+	rowSize := flags.Int("row-size", engine.DefaultRowSize, "number slots per player (3..10)")
+	exact := flags.Bool("exact", false, "keep numbers as exact fractions where the cards allow it")
+	precision := flags.Uint("precision", model.DefaultPrec, "bits of precision for roots, logarithms, trigonometry and constants (53..4096)")
+	onFailure := flags.String("on-failure", "refund", "what happens to a queued card that fails: refund, discard or abort (the whole turn)")
+
+	return func() model.Settings {
+		settings := engine.DefaultSettings()
+		if *packs != "" {
+			settings.Packs = strings.Split(*packs, ",")
+		}
+		settings.HandSize = *handSize
+		settings.RowSize = *rowSize
+		settings.FailurePolicy = strings.ToUpper(*onFailure)
+		settings.Exact = *exact
+		settings.Precision = *precision
+		return settings
+	}
+}
+
+func deckFlag(flags *flag.FlagSet) *string {
+	return flags.String("cards", "", "csv or json file of cards that override (by Method) or extend the built-in deck")
+}
+
+// Loads the --cards file on top of the built-in deck and checks the result,
+// so a bad deck fails before any game starts
+func loadDeck(path string) {
+	if path != "" {
+		if err := cards.LoadFile(path); err != nil {
+			log.Fatalf("invalid deck: %v", err)
+		}
+		log.Printf("deck extended with the cards in %s", path)
+	}
+	if err := cards.Validate(); err != nil {
+		log.Fatalf("invalid deck: %v", err)
+	}
+}
+
+func listPacks() {
+	packs, err := engine.AvailablePacks()
+	if err != nil {
+		log.Fatalf("failed to read packs: %v", err)
+	}
+	for _, pack := range packs {
+		fmt.Printf("%s (%d cards): %s\n", pack.Name, pack.Size, strings.Join(pack.Cards, ", "))
+	}
+}
+
 func clientSplash() {
 	utils.MatetraSplash()
 	fmt.Println("to start a game:")
-	fmt.Println("	matetra-server start <game-title>")
+	fmt.Println("	matetra-server start [--seed <n>] [--journal <file>] [--packs <pack,...>]")
+	fmt.Println("		[--hand-size <4..10>] [--row-size <3..10>] [--on-failure refund|discard|abort]")
+	fmt.Println("		[--exact] [--precision <bits>] [--cards <file>] [--save <file>] [--autosave <duration>] <game-title>")
 	fmt.Println(" ex: matetra-server start WonderfulGame")
+	fmt.Println(" ex: matetra-server start --seed 1729 --journal game.jsonl WonderfulGame")
+	fmt.Println(" ex: matetra-server start --cards house-rules.csv WonderfulGame")
+	fmt.Println("to bring back the games saved with --save:")
+	fmt.Println("	matetra-server resume [--autosave <duration>] [--journal <file>] [--cards <file>] <save-file>")
+	fmt.Println("to list the card packs:")
+	fmt.Println("	matetra-server packs [--cards <file>]")
+	fmt.Println("to play bot-only games and report how every card does:")
+	fmt.Println("	matetra-server simulate [--games <n>] [--bots <strategy,...>] [--turns <n>] [--seed <n>]")
+	fmt.Println("		[--format csv|json] [--out <file>] [--workers <n>] [--cards <file>] [game settings flags]")
+	fmt.Println(" ex: matetra-server simulate --games 5000 --bots greedy,greedy,random --format json")
+	fmt.Println("to step through a recorded game:")
+	fmt.Println("	matetra-server replay [--cards <file>] <journal-file>")
 }
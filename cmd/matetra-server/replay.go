@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+	"github.com/umarbektokyo/matetra-engine/replay"
+)
+
+// Steps through a recorded game turn by turn
+func replayGame(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("failed to open journal: %v", err)
+	}
+	defer f.Close()
+
+	events, err := replay.Load(f)
+	if err != nil {
+		log.Fatalf("failed to read journal: %v", err)
+	}
+
+	r, err := replay.New(events)
+	if r == nil {
+		log.Fatalf("failed to replay journal: %v", err)
+	}
+	if err != nil {
+		// show whatever could be rebuilt before the problem
+		log.Printf("replay stopped early: %v", err)
+	}
+	if len(r.Frames) == 0 {
+		log.Fatalf("nothing to replay, the game never started")
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	current := 0
+	for {
+		displayFrame(r, current)
+
+		fmt.Print("\n>>> ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		parts := strings.Fields(input)
+		if len(parts) == 0 {
+			parts = []string{"next"}
+		}
+
+		switch strings.ToLower(parts[0]) {
+		case "n", "next":
+			if current < len(r.Frames)-1 {
+				current++
+			}
+		case "p", "prev":
+			if current > 0 {
+				current--
+			}
+		case "g", "goto":
+			if len(parts) < 2 {
+				continue
+			}
+			turn, err := strconv.Atoi(parts[1])
+			if err != nil {
+				continue
+			}
+			for i, frame := range r.Frames {
+				if frame.Turn == turn {
+					current = i
+					break
+				}
+			}
+		case "q", "quit", "exit":
+			return
+		}
+	}
+}
+
+func displayFrame(r *replay.Replay, index int) {
+	frame := r.Frames[index]
+	gs := frame.State
+
+	fmt.Print("\033[H\033[2J") // Clear terminal screen
+	fmt.Println("=====================================================================")
+	fmt.Printf(" REPLAY: %s | SEED: %d | TURN: %d | FRAME %d/%d | %s\n", r.GameID, r.Seed, frame.Turn, index+1, len(r.Frames), gs.Phase)
+	fmt.Println("=====================================================================")
+
+	fmt.Println("\n--- WHAT HAPPENED ---")
+	for _, e := range frame.Events {
+		fmt.Printf("  %s\n", describeEvent(gs, e))
+	}
+
+	fmt.Println("\n--- PLAYER NUMBERS ---")
+	for i, p := range gs.Players {
+		numberStrings := make([]string, len(gs.Numbers[i]))
+		for j, num := range gs.Numbers[i] {
+			numberStrings[j] = fmt.Sprintf("[%d:%s%s]", j, num.String(), num.Mark)
+		}
+		fmt.Printf("  @%s (ID: %d): %s\n", p.Name, i, strings.Join(numberStrings, " | "))
+		for j, num := range gs.Numbers[i] {
+			if num.Expr != nil && num.Mark != "n" {
+				fmt.Printf("      [%d] = %s\n", j, num.Expr.String())
+			}
+		}
+	}
+
+	fmt.Println("---------------------------------------------------------------------")
+	fmt.Println("  next / prev / goto <turn> / quit")
+}
+
+func describeEvent(gs *model.GameState, e model.Event) string {
+	player := "-"
+	if e.Player >= 0 && e.Player < len(gs.Players) {
+		player = "@" + gs.Players[e.Player].Name
+	}
+	cardName := func(index int) string {
+		if index >= 0 && index < len(gs.Cards) {
+			return gs.Cards[index].Name
+		}
+		return "unknown card"
+	}
+
+	switch e.Type {
+	case model.EventGameCreated:
+		return fmt.Sprintf("game %s created with seed %d", e.Name, e.Seed)
+	case model.EventPlayerJoined:
+		return fmt.Sprintf("@%s joined", e.Name)
+	case model.EventCardQueued:
+		return fmt.Sprintf("%s queued %s (Inputs: %v)", player, cardName(e.Card), e.Inputs)
+	case model.EventCardWithdrawn:
+		return fmt.Sprintf("%s withdrew %s", player, cardName(e.Card))
+	case model.EventDiceRolled:
+		return fmt.Sprintf("%s rolled %d into slot %d", player, e.Value, e.Slot)
+	case model.EventQueueResolved:
+		names := make([]string, len(e.Order))
+		for i, index := range e.Order {
+			names[i] = cardName(index)
+		}
+		return fmt.Sprintf("queue resolved: %s", strings.Join(names, " -> "))
+	case model.EventTurnAdvanced:
+		return fmt.Sprintf("turn %d started", e.Turn)
+	case model.EventGameOver:
+		return fmt.Sprintf("game over, winners: %v", e.Winners)
+	default:
+		return fmt.Sprintf("%s %s", player, strings.ToLower(e.Type))
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/umarbektokyo/matetra-engine/api"
+	"github.com/umarbektokyo/matetra-engine/utils"
+)
+
+// Runs the server, saving every game to savePath periodically and on
+// shutdown. The journals are flushed and closed on shutdown.
+func serve(apiServer *api.API, savePath string, autosave time.Duration) {
+	if savePath != "" {
+		if autosave > 0 {
+			go apiServer.Autosave(savePath, autosave)
+		}
+		log.Printf("saving games to %s every %s", savePath, autosave)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-stop
+		code := 0
+		if savePath != "" {
+			log.Printf("%s received, saving games to %s", sig, savePath)
+			if err := apiServer.Save(savePath); err != nil {
+				log.Printf("failed to save games: %v", err)
+				code = 1
+			}
+		}
+		apiServer.CloseJournals()
+		os.Exit(code)
+	}()
+
+	apiServer.Start()
+}
+
+// Brings back the games saved in a file and keeps saving to it
+func resumeServer(args []string) {
+	flags := flag.NewFlagSet("resume", flag.ExitOnError)
+	autosave := flags.Duration("autosave", time.Minute, "how often to save the games (0 saves on shutdown only)")
+	journalPath := flags.String("journal", "", "keep appending every game event to the JSONL files given to start --journal")
+	deckPath := deckFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		fmt.Println("usage: matetra-server resume [--autosave <duration>] [--journal <file>] [--cards <file>] <save-file>")
+		return
+	}
+	savePath := flags.Arg(0)
+
+	utils.MatetraSplash()
+	// saved games keep their cards, the deck is for the games created afterwards
+	loadDeck(*deckPath)
+	apiServer, err := api.Restore(savePath, *journalPath)
+	if err != nil {
+		log.Fatalf("failed to resume: %v", err)
+	}
+	serve(apiServer, savePath, *autosave)
+}
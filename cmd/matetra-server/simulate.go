@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/sim"
+)
+
+// Plays bot-only games without networking and writes a card balance report
+func simulate(args []string) {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	games := flags.Int("games", 1000, "number of games to play")
+	bots := flags.String("bots", "greedy,random", "comma separated strategy of every seat")
+	turns := flags.Int("turns", 50, "the highest row sum wins after this many turns, unless another victory condition is met first (0 disables)")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the first game, the others follow (default: current time)")
+	format := flags.String("format", "csv", "report format: csv (one row per card) or json (cards and game totals)")
+	outPath := flags.String("out", "", "write the report to this file (default: stdout)")
+	workers := flags.Int("workers", 0, "games played at the same time (default: one per CPU)")
+	gameSettings := settingsFlags(flags)
+	deckPath := deckFlag(flags)
+	flags.Parse(args)
+	loadDeck(*deckPath)
+
+	settings := gameSettings()
+	if *turns > 0 {
+		settings.Victory = append(settings.Victory, engine.HighestSum(*turns))
+	}
+
+	var write func(*sim.Report, io.Writer) error
+	switch strings.ToLower(*format) {
+	case "csv":
+		write = (*sim.Report).WriteCSV
+	case "json":
+		write = (*sim.Report).WriteJSON
+	default:
+		log.Fatalf("unknown report format %s, expected csv or json", *format)
+	}
+
+	cfg := sim.Config{
+		Games:    *games,
+		Bots:     strings.Split(*bots, ","),
+		Settings: settings,
+		Seed:     *seed,
+		Workers:  *workers,
+	}
+	log.Printf("simulating %d games of %s with seed %d", cfg.Games, strings.Join(cfg.Bots, " vs "), cfg.Seed)
+	start := time.Now()
+	report, err := sim.Run(cfg)
+	if err != nil {
+		log.Fatalf("simulation failed: %v", err)
+	}
+	log.Printf("%d games (%d finished) in %s, %.1f turns on average, numbers end around 10^%.1f, wins: %v",
+		report.Games, report.Finished, time.Since(start).Round(time.Millisecond), report.AvgTurns, report.AvgMagnitude, report.Wins)
+
+	out := io.Writer(os.Stdout)
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("failed to create report: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := write(report, out); err != nil {
+		log.Fatalf("failed to write report: %v", err)
+	}
+	if *outPath != "" {
+		fmt.Printf("report written to %s\n", *outPath)
+	}
+}
@@ -1,10 +1,13 @@
 package engine
 
 import (
+	"crypto/subtle"
 	"fmt"
-	"math/big"
-	"math/rand"
+	"io"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/umarbektokyo/matetra-engine/cards"
 	"github.com/umarbektokyo/matetra-engine/cards/constants"
@@ -13,53 +16,101 @@ import (
 )
 
 type Game struct {
-	State *model.GameState
-	mu    sync.RWMutex
+	State      *model.GameState
+	mu         sync.RWMutex
+	journal    []model.Event
+	journalOut io.Writer
 }
 
-// Initializes a new empty game
+// Initializes a new empty game seeded from the clock
 func New(gameID string) *Game {
-	return &Game{
+	return NewSeeded(gameID, time.Now().UnixNano())
+}
+
+// Settings of a new game
+func DefaultSettings() model.Settings {
+	return model.Settings{
+		Victory:   []model.VictoryCondition{TargetNumber(1729)},
+		HandSize:  DefaultHandSize,
+		RowSize:   DefaultRowSize,
+		Precision: model.DefaultPrec,
+
+		FailurePolicy: model.FailRefund,
+	}
+}
+
+// Initializes a new empty game with a fixed seed, same seed and moves give the same game
+func NewSeeded(gameID string, seed int64) *Game {
+	g := &Game{
 		State: &model.GameState{
-			GameID:  gameID,
-			Players: []model.Player{},
-			Cards:   []model.Card{},
-			Numbers: make([][5]model.Number, 0),
-			Done:    make([]bool, 0),
-			Queue:   make([]int, 0),
-			Turn:    0,
+			GameID:   gameID,
+			Phase:    model.PhaseLobby,
+			Seed:     seed,
+			RNG:      model.NewRNG(seed),
+			Settings: DefaultSettings(),
+			Players:  []model.Player{},
+			Ready:    make([]bool, 0),
+			Cards:    []model.Card{},
+			Numbers:  make([][]model.Number, 0),
+			Done:     make([]bool, 0),
+			Queue:    make([]int, 0),
+			Turn:     0,
 		},
 	}
+	// a replay needs the same deck to deal the same cards
+	deck, _ := cards.Fingerprint()
+	g.record(model.Event{Type: model.EventGameCreated, Player: -1, Name: gameID, Seed: seed, Deck: deck})
+	return g
 }
 
 func NewNumber() model.Number {
-	return model.Number{
-		Value: big.NewFloat(0),
-		Mark:  "n",
-	}
+	var n model.Number
+	n.Clear()
+	return n
 }
 
-func NewNumberRow() (row [5]model.Number) {
+func NewNumberRow(size int) []model.Number {
+	row := make([]model.Number, size)
 	for i := range row {
 		row[i] = NewNumber()
 	}
-	return
+	return row
 }
 
-// Adds a new player to the game
-func (g *Game) AddPlayer(name, hash string) (int, error) {
+// Adds a new player to the game, a returning player whose hash matches gets
+// their seat back (rejoined is true), in any phase
+func (g *Game) AddPlayer(name, hash string) (playerID int, rejoined bool, err error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	if strings.TrimSpace(name) == "" {
+		return -1, false, fmt.Errorf("the name cannot be empty")
+	}
+	for i, p := range g.State.Players {
+		if !strings.EqualFold(p.Name, name) {
+			continue
+		}
+		if p.Name != name || subtle.ConstantTimeCompare([]byte(p.Hash), []byte(hash)) != 1 {
+			return -1, false, fmt.Errorf("the name @%s is already taken", p.Name)
+		}
+		return i, true, nil
+	}
+
+	if g.State.Phase != model.PhaseLobby {
+		return -1, false, fmt.Errorf("cannot join, the game has already started")
+	}
+
 	// Adds a new player object
-	playerID := len(g.State.Players)
+	playerID = len(g.State.Players)
 	g.State.Players = append(g.State.Players, model.Player{
 		Name: name,
 		Hash: hash,
 	})
-	g.State.Numbers = append(g.State.Numbers, NewNumberRow())
+	g.State.Numbers = append(g.State.Numbers, NewNumberRow(g.State.Settings.RowSize))
 	g.State.Done = append(g.State.Done, false)
-	return playerID, nil
+	g.State.Ready = append(g.State.Ready, false)
+	g.record(model.Event{Type: model.EventPlayerJoined, Player: playerID, Name: name})
+	return playerID, false, nil
 }
 
 // Return the index of the player whoose turn it is
@@ -74,13 +125,45 @@ func (g *Game) CurrentPlayer() int {
 	return g.State.Turn % n
 }
 
-// Loads the card deck into game state
-func (g *Game) LoadCards() {
+// Internal version (no lock)
+func (g *Game) loadCards() error {
+	deck, err := cards.LoadCards(g.State.Settings.Packs...)
+	if err != nil {
+		return err
+	}
+	if len(deck) == 0 {
+		return fmt.Errorf("the selected packs have no cards")
+	}
+	// views keep card indices, a fixed order would give hidden cards away.
+	// The IDs follow the new order so they give nothing away either.
+	g.State.RNG.Shuffle(len(deck), func(i, j int) {
+		deck[i], deck[j] = deck[j], deck[i]
+	})
+	cards.AssignIDs(deck)
+	g.State.Cards = deck
+	return nil
+}
+
+// Builds the card deck from the packs in the settings
+func (g *Game) LoadCards() error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	deck := utils.Must(cards.LoadCards())
-	g.State.Cards = append(g.State.Cards, deck...)
+	return g.loadCards()
+}
+
+func validatePacks(packs []string) error {
+	for _, pack := range packs {
+		if !cards.HasPack(pack) {
+			return fmt.Errorf("unknown pack %s", pack)
+		}
+	}
+	return nil
+}
+
+// Lists the packs a deck can be built from
+func AvailablePacks() ([]model.Pack, error) {
+	return cards.Packs()
 }
 
 // Check if everyone has finished the turn
@@ -120,7 +203,7 @@ func (g *Game) restockCards() {
 			}
 		}
 
-		for handCount < 6 {
+		for handCount < g.State.Settings.HandSize {
 			// Build a deck
 			deck := []int{}
 			for i, c := range g.State.Cards {
@@ -148,45 +231,57 @@ func (g *Game) restockCards() {
 				break
 			}
 			// Choose a card from a deck
-			idx := deck[rand.Intn(len(deck))]
+			idx := deck[g.State.RNG.IntN(len(deck))]
 			g.State.Cards[idx].Owner = p
 			handCount++
 		}
 	}
 }
 
-// Fills everyone's hands up (6 cards max) (needs optimisation)
+// Fills everyone's hands up to the hand size (needs optimisation)
 func (g *Game) RestockCards() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	g.restockCards()
 }
 
-// Internal version (no lock)
-func (g *Game) copyState() *model.GameState {
+// Deep copies any game state
+func cloneState(gs *model.GameState) *model.GameState {
 	virtual := &model.GameState{
-		GameID:  g.State.GameID,
-		Players: append([]model.Player(nil), g.State.Players...),
-		Cards:   append([]model.Card(nil), g.State.Cards...),
-		Numbers: make([][5]model.Number, len(g.State.Numbers)),
-		Done:    append([]bool(nil), g.State.Done...),
-		Queue:   append([]int(nil), g.State.Queue...),
-		Turn:    g.State.Turn,
+		GameID:    gs.GameID,
+		Phase:     gs.Phase,
+		Seed:      gs.Seed,
+		RNG:       gs.RNG.Clone(),
+		Settings:  gs.Settings,
+		Players:   append([]model.Player(nil), gs.Players...),
+		Ready:     append([]bool(nil), gs.Ready...),
+		Cards:     append([]model.Card(nil), gs.Cards...),
+		Numbers:   make([][]model.Number, len(gs.Numbers)),
+		Done:      append([]bool(nil), gs.Done...),
+		Queue:     append([]int(nil), gs.Queue...),
+		Resolved:  append([]model.Resolution(nil), gs.Resolved...),
+		Turn:      gs.Turn,
+		Winners:   append([]int(nil), gs.Winners...),
+		Standings: append([]model.Standing(nil), gs.Standings...),
 	}
+	virtual.Settings.Victory = append([]model.VictoryCondition(nil), gs.Settings.Victory...)
+	virtual.Settings.Packs = append([]string(nil), gs.Settings.Packs...)
 
-	for i := range g.State.Numbers {
-		for j := 0; j < 5; j++ {
-			orig := g.State.Numbers[i][j]
-			virtual.Numbers[i][j] = model.Number{
-				Mark:  orig.Mark,
-				Value: new(big.Float).Set(orig.Value),
-			}
+	for i := range gs.Numbers {
+		virtual.Numbers[i] = make([]model.Number, len(gs.Numbers[i]))
+		for j := range gs.Numbers[i] {
+			virtual.Numbers[i][j] = gs.Numbers[i][j].Clone()
 		}
 	}
 
 	return virtual
 }
 
+// Internal version (no lock)
+func (g *Game) copyState() *model.GameState {
+	return cloneState(g.State)
+}
+
 // Makes a virtual deep copy of the game state
 func (g *Game) CopyState() *model.GameState {
 	g.mu.RLock()
@@ -208,19 +303,63 @@ func (g *Game) ApplyCard(vgs *model.GameState, cardIndex int) error {
 	return nil
 }
 
-// Applies all the Cards in Queue
-func (g *Game) ApplyCards(vgs *model.GameState) error {
-	for _, cardIndex := range vgs.Queue {
+// Order in which the queue resolves: higher Precedence first, ties go in seat
+// order starting from the current player, and a player's own cards keep the
+// order they were queued in.
+func ResolutionOrder(vgs *model.GameState) []int {
+	order := append([]int(nil), vgs.Queue...)
+	n := len(vgs.Players)
+	if n == 0 {
+		return order
+	}
+
+	current := vgs.Turn % n
+	seat := func(cardIndex int) int {
+		return (vgs.Cards[cardIndex].Owner - current + n) % n
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := vgs.Cards[order[i]], vgs.Cards[order[j]]
+		if a.Precedence != b.Precedence {
+			return a.Precedence > b.Precedence
+		}
+		return seat(order[i]) < seat(order[j])
+	})
+	return order
+}
+
+// Applies the player's queued cards in resolution order, stops at the first
+// card that fails (used for previews). A preview does not know the inputs of
+// the other players' cards, they stay queued for counter cards to target.
+func (g *Game) ApplyCards(vgs *model.GameState, playerID int) error {
+	order := ResolutionOrder(vgs)
+
+	resolved := make([]model.Resolution, 0, len(order))
+	for _, cardIndex := range order {
+		if vgs.Cards[cardIndex].Owner != playerID {
+			continue
+		}
+		if !dequeue(vgs, cardIndex) {
+			resolved = append(resolved, model.Resolution{Card: cardIndex, Owner: playerID, Outcome: model.OutcomeCountered})
+			continue
+		}
 		err := g.ApplyCard(vgs, cardIndex)
 		if err != nil {
 			return err
 		}
+		resolved = append(resolved, model.Resolution{Card: cardIndex, Owner: playerID, Outcome: model.OutcomeApplied})
 	}
-	vgs.Queue = nil
+	vgs.Resolved = resolved
 
 	return nil
 }
 
+// Random source of a preview, made up from the turn so a preview never shows
+// the rolls the game will really make
+func previewRNG(vgs *model.GameState) *model.RNG {
+	return model.NewRNG(int64(vgs.Turn))
+}
+
 // Internal version (no lock)
 func (g *Game) nextTurn() error {
 	virtual := g.copyState()
@@ -245,11 +384,11 @@ func (g *Game) nextTurn() error {
 
 				a, b := int64(1), int64(2)
 				if v == 1 {
-					g.State.Numbers[i][j].Value = big.NewFloat(2)
+					g.State.Numbers[i][j].SetInt64(2)
 				} else {
 					for b <= v {
 						if b == v {
-							g.State.Numbers[i][j].Value = big.NewFloat(float64(a + b))
+							g.State.Numbers[i][j].SetInt64(a + b)
 							break
 						}
 						a, b = b, a+b
@@ -279,16 +418,49 @@ func (g *Game) PlayerCanPlayCard(playerID, cardIndex int) bool {
 	return g.State.Cards[cardIndex].Owner == playerID
 }
 
-// API: Moves
-func (g *Game) ProcessMove(playerID int, cardIndex int, inputs []int, permanent bool) (*model.GameState, error) {
+// Internal version (no lock), state a preview starts from: what the player
+// can see, hidden cards stay hidden and the dice are not the live ones
+func (g *Game) previewState(playerID int) *model.GameState {
+	virtual := cloneState(ViewFor(g.State, playerID))
+	virtual.RNG = previewRNG(virtual)
+	return virtual
+}
+
+// Previews what the player's queue does as it is, without another card
+func (g *Game) PreviewQueue(playerID int) (*model.GameState, error) {
 	g.mu.RLock()
+	if err := g.checkPlaying(); err != nil {
+		g.mu.RUnlock()
+		return nil, err
+	}
+	virtual := g.previewState(playerID)
+	g.mu.RUnlock()
+
+	if err := g.ApplyCards(virtual, playerID); err != nil {
+		return nil, fmt.Errorf("calculation failed: %v", err)
+	}
+	return virtual, nil
+}
 
+// API: Moves
+func (g *Game) ProcessMove(playerID int, cardIndex int, inputs []int, permanent bool) (*model.GameState, error) {
 	// check ownership
 	if !g.PlayerCanPlayCard(playerID, cardIndex) {
-		g.mu.RUnlock()
 		return nil, fmt.Errorf("you do not own this card")
 	}
 
+	g.mu.RLock()
+
+	if err := g.checkPlaying(); err != nil {
+		g.mu.RUnlock()
+		return nil, err
+	}
+
+	if queuePosition(g.State, cardIndex) != -1 {
+		g.mu.RUnlock()
+		return nil, fmt.Errorf("this card is already queued")
+	}
+
 	// validate input
 	expected := len(g.State.Cards[cardIndex].InputsReq)
 	if len(inputs) != expected {
@@ -296,22 +468,20 @@ func (g *Game) ProcessMove(playerID int, cardIndex int, inputs []int, permanent
 		return nil, fmt.Errorf("expected %d inputs but got %d", expected, len(inputs))
 	}
 
-	g.mu.RUnlock()
-
 	// Virtual state for preview/calculation
-	virtual := g.CopyState()
+	virtual := g.previewState(playerID)
+
+	g.mu.RUnlock()
 
 	// Apply the specific move to the virtual state (queue it)
 	vCard := &virtual.Cards[cardIndex]
 	vCard.Inputs = append([]int(nil), inputs...)
 	virtual.Queue = append(virtual.Queue, cardIndex)
 
-	// Execute ALL queued cards on the virtual state to get the final result
-	fmt.Printf("[DEBUG] ProcessMove: Applying queue on virtual state. Queue len: %d\n", len(virtual.Queue))
-	if err := g.ApplyCards(virtual); err != nil {
+	// Execute the player's queued cards on the virtual state to get the result
+	if err := g.ApplyCards(virtual, playerID); err != nil {
 		return nil, fmt.Errorf("calculation failed: %v", err)
 	}
-	fmt.Printf("[DEBUG] ProcessMove: Applied. Virtual Numbers[0]: %v\n", virtual.Numbers[0])
 
 	if !permanent {
 		// Non-permanent: just return the virtual calculated state
@@ -321,10 +491,18 @@ func (g *Game) ProcessMove(playerID int, cardIndex int, inputs []int, permanent
 		g.mu.Lock()
 		defer g.mu.Unlock()
 
+		if err := g.checkPlaying(); err != nil {
+			return nil, err
+		}
+
 		if g.State.Done[playerID] {
 			return nil, fmt.Errorf("you have already finished your turn")
 		}
 
+		if queuePosition(g.State, cardIndex) != -1 {
+			return nil, fmt.Errorf("this card is already queued")
+		}
+
 		// Validation on live state
 		originalInputs := g.State.Cards[cardIndex].Inputs
 		g.State.Cards[cardIndex].Inputs = append([]int(nil), inputs...)
@@ -336,22 +514,101 @@ func (g *Game) ProcessMove(playerID int, cardIndex int, inputs []int, permanent
 
 		// Queue in real state
 		g.State.Queue = append(g.State.Queue, cardIndex)
+		g.record(model.Event{
+			Type:    model.EventCardQueued,
+			Player:  playerID,
+			Card:    cardIndex,
+			CardID:  g.State.Cards[cardIndex].ID,
+			Inputs:  append([]int(nil), inputs...),
+			Targets: targets(g.State, &g.State.Cards[cardIndex]),
+		})
 
 		// Return the VIRTUAL state (which has the queue applied) for display
 		return virtual, nil
 	}
 }
 
+// IDs of the cards the card's c inputs name, nil without c inputs
+func targets(vgs *model.GameState, card *model.Card) []string {
+	var ids []string
+	// the inputs are validated, so is their InputsReq
+	specs, _ := model.InputSpecs(card.InputsReq)
+	for i, spec := range specs {
+		if spec.Kind == model.InputCard {
+			ids = append(ids, vgs.Cards[card.Inputs[i]].ID)
+		}
+	}
+	return ids
+}
+
+// Index of the card with the ID, card IDs are stable for the whole game
+func (g *Game) CardIndex(id string) (int, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for i, card := range g.State.Cards {
+		if card.ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("there is no card %s", id)
+}
+
+// Returns where the card sits in the queue, -1 if it is not queued
+func queuePosition(vgs *model.GameState, cardIndex int) int {
+	for i, queued := range vgs.Queue {
+		if queued == cardIndex {
+			return i
+		}
+	}
+	return -1
+}
+
+// API: Takes a queued card back into the player's hand before the turn resolves
+func (g *Game) WithdrawCard(playerID int, cardIndex int) (*model.GameState, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkPlaying(); err != nil {
+		return nil, err
+	}
+
+	if cardIndex < 0 || cardIndex >= len(g.State.Cards) || g.State.Cards[cardIndex].Owner != playerID {
+		return nil, fmt.Errorf("you do not own this card")
+	}
+
+	if g.State.Done[playerID] {
+		return nil, fmt.Errorf("you have already finished your turn")
+	}
+
+	pos := queuePosition(g.State, cardIndex)
+	if pos == -1 {
+		return nil, fmt.Errorf("this card is not queued")
+	}
+
+	g.State.Queue = append(g.State.Queue[:pos], g.State.Queue[pos+1:]...)
+	// cards in hand carry no inputs
+	g.State.Cards[cardIndex].Inputs = []int{}
+	g.record(model.Event{Type: model.EventCardWithdrawn, Player: playerID, Card: cardIndex, CardID: g.State.Cards[cardIndex].ID})
+
+	return g.copyState(), nil
+}
+
 // API: Turns
 func (g *Game) ProcessNextTurn(playerID int) (*model.GameState, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	if err := g.checkPlaying(); err != nil {
+		return nil, err
+	}
+
 	if g.State.Done[playerID] {
 		return nil, fmt.Errorf("you have already finished your turn")
 	}
 
 	g.State.Done[playerID] = true
+	g.record(model.Event{Type: model.EventTurnEnded, Player: playerID})
 
 	finished := true
 	for _, done := range g.State.Done {
@@ -362,17 +619,26 @@ func (g *Game) ProcessNextTurn(playerID int) (*model.GameState, error) {
 	}
 
 	if finished {
-		// execute all queued cards
-		if err := g.ApplyCards(g.State); err != nil {
-			// If application fails at this stage, it's problematic because turn is "done".
-			// But for now, returning error is all we can do.
-			// Ideally we should have validated everything perfectly before.
-			return nil, fmt.Errorf("failed to apply queued cards: %v", err)
+		// execute all queued cards, failures are handled by the failure policy
+		g.State = g.resolveQueue(g.State)
+
+		order := make([]int, len(g.State.Resolved))
+		for i, r := range g.State.Resolved {
+			order[i] = r.Card
 		}
+		g.record(model.Event{Type: model.EventQueueResolved, Player: -1, Order: order})
 
 		if err := g.nextTurn(); err != nil {
 			return nil, err
 		}
+		g.record(model.Event{Type: model.EventTurnAdvanced, Player: -1})
+
+		if err := g.checkVictory(); err != nil {
+			return nil, err
+		}
+		if g.State.Phase == model.PhaseFinished {
+			g.record(model.Event{Type: model.EventGameOver, Player: -1, Winners: g.State.Winners})
+		}
 	}
 
 	return g.copyState(), nil
@@ -383,6 +649,10 @@ func (g *Game) ProcessDiceRoll(playerID int) (*model.GameState, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	if err := g.checkPlaying(); err != nil {
+		return nil, err
+	}
+
 	// 1. check if it is the player's turn
 	if len(g.State.Players) == 0 || playerID != (g.State.Turn%len(g.State.Players)) {
 		return nil, fmt.Errorf("it is not your turn")
@@ -412,6 +682,14 @@ func (g *Game) ProcessDiceRoll(playerID int) (*model.GameState, error) {
 		return nil, err
 	}
 
+	rolled, _ := g.State.Numbers[playerID][firstEmptySlot].Value.Int64()
+	g.record(model.Event{
+		Type:   model.EventDiceRolled,
+		Player: playerID,
+		Slot:   firstEmptySlot,
+		Value:  int(rolled),
+	})
+
 	// Return a copy of the updated state
 	return g.copyState(), nil
 }
@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Two players in a started game
+func startedGame(t *testing.T) *Game {
+	t.Helper()
+	g := NewSeeded("test", 1)
+	for _, name := range []string{"ann", "bob"} {
+		player, _, err := g.AddPlayer(name, "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.SetReady(player, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := g.StartGame(0); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// Hands a card with the method to the player, returns its index
+func giveCard(t *testing.T, g *Game, method string, player int) int {
+	t.Helper()
+	for i, card := range g.State.Cards {
+		if card.Method == method && card.Owner == -1 {
+			g.State.Cards[i].Owner = player
+			return i
+		}
+	}
+	t.Fatalf("no %s card left in the deck", method)
+	return -1
+}
+
+func TestPreviewShowsOnlyWhatThePlayerSees(t *testing.T) {
+	g := startedGame(t)
+	mine := giveCard(t, g, "CONST7", 0)
+	theirs := giveCard(t, g, "CONST73", 1)
+	if _, err := g.ProcessMove(1, theirs, nil, true); err != nil {
+		t.Fatal(err)
+	}
+
+	rng, _ := g.State.RNG.MarshalBinary()
+	preview, err := g.ProcessMove(0, mine, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := g.State.RNG.MarshalBinary(); !bytes.Equal(rng, after) {
+		t.Errorf("the preview moved the live random source")
+	}
+
+	for i, card := range preview.Cards {
+		if card.Owner == 1 && i != theirs && !card.Hidden {
+			t.Errorf("card %d in bob's hand is visible in ann's preview", i)
+		}
+		if card.Owner == -1 && !card.Hidden {
+			t.Errorf("deck card %d is visible in the preview", i)
+		}
+	}
+	if queuePosition(preview, theirs) == -1 {
+		t.Errorf("bob's queued card should stay queued in ann's preview")
+	}
+	for j, num := range preview.Numbers[1] {
+		if num.Mark != g.State.Numbers[1][j].Mark {
+			t.Errorf("ann's preview applied bob's card: slot %d is %q", j, num.Mark)
+		}
+	}
+	if preview.Seed != 0 || len(preview.Players[1].Hash) != 0 {
+		t.Errorf("the preview shows the seed or a password hash")
+	}
+}
+
+func TestPreviewDiceAreNotTheLiveOnes(t *testing.T) {
+	g := startedGame(t)
+	mine := giveCard(t, g, "CONST7", 0)
+
+	first, err := g.ProcessMove(0, mine, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// whatever the live source rolls next, the preview stays the same
+	for i := 0; i < 10; i++ {
+		g.State.RNG.IntN(6)
+	}
+	second, err := g.ProcessMove(0, mine, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := range first.Numbers[0] {
+		a, b := first.Numbers[0][j], second.Numbers[0][j]
+		if a.Mark != b.Mark || (a.Value != nil && a.Cmp(&b) != 0) {
+			t.Errorf("slot %d differs between previews: %s and %s", j, a.String(), b.String())
+		}
+	}
+}
+
+func TestApplyCardsSkipsOtherPlayers(t *testing.T) {
+	g := startedGame(t)
+	mine := giveCard(t, g, "CONST73", 0)
+	theirs := giveCard(t, g, "CONST7", 1)
+
+	vgs := cloneState(g.State)
+	vgs.Queue = []int{theirs, mine}
+	vgs.Cards[mine].Inputs = []int{}
+	if err := g.ApplyCards(vgs, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(vgs.Resolved) != 1 || vgs.Resolved[0].Card != mine || vgs.Resolved[0].Outcome != model.OutcomeApplied {
+		t.Errorf("resolved %+v, want only card %d applied", vgs.Resolved, mine)
+	}
+	if len(vgs.Queue) != 1 || vgs.Queue[0] != theirs {
+		t.Errorf("queue %v, want bob's card %d still queued", vgs.Queue, theirs)
+	}
+}
+
+func TestPreviewQueueShowsOnlyWhatThePlayerSees(t *testing.T) {
+	g := startedGame(t)
+	mine := giveCard(t, g, "CONST7", 0)
+	if _, err := g.ProcessMove(0, mine, nil, true); err != nil {
+		t.Fatal(err)
+	}
+
+	rng, _ := g.State.RNG.MarshalBinary()
+	preview, err := g.PreviewQueue(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := g.State.RNG.MarshalBinary(); !bytes.Equal(rng, after) {
+		t.Errorf("the preview moved the live random source")
+	}
+	if len(preview.Resolved) != 1 || preview.Resolved[0].Card != mine {
+		t.Errorf("resolved %+v, want card %d applied", preview.Resolved, mine)
+	}
+	for i, card := range preview.Cards {
+		if card.Owner == -1 && !card.Hidden {
+			t.Errorf("deck card %d is visible in the preview", i)
+		}
+	}
+	if queuePosition(g.State, mine) == -1 {
+		t.Errorf("the preview dequeued the live card")
+	}
+}
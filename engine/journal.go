@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"time"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Internal version (no lock), appends the event to the journal and the journal writer
+func (g *Game) record(e model.Event) {
+	e.Seq = len(g.journal)
+	e.Time = time.Now()
+	e.Turn = g.State.Turn
+	g.journal = append(g.journal, e)
+
+	if g.journalOut != nil {
+		g.writeEvent(e)
+	}
+}
+
+// Internal version (no lock)
+func (g *Game) writeEvent(e model.Event) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("failed to encode journal event %d: %v", e.Seq, err)
+		return
+	}
+	line = append(line, '\n')
+	if _, err := g.journalOut.Write(line); err != nil {
+		log.Printf("failed to write journal event %d: %v", e.Seq, err)
+	}
+}
+
+// Streams every event as a JSON line to w, starting with the ones already
+// recorded, nil stops streaming
+func (g *Game) SetJournalWriter(w io.Writer) {
+	g.ResumeJournalWriter(w, 0)
+}
+
+// Like SetJournalWriter for a w that already holds the first written events,
+// e.g. the journal file of a restored game
+func (g *Game) ResumeJournalWriter(w io.Writer, written int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.journalOut = w
+	if w == nil {
+		return
+	}
+	for _, e := range g.journal[min(max(written, 0), len(g.journal)):] {
+		g.writeEvent(e)
+	}
+}
+
+// Returns a copy of every event recorded so far
+func (g *Game) Journal() []model.Event {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]model.Event(nil), g.journal...)
+}
@@ -0,0 +1,202 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// The first player to join hosts the game
+const hostPlayer = 0
+
+// Setting defaults and limits
+const (
+	DefaultHandSize = 6
+	MinHandSize     = 4
+	MaxHandSize     = 10
+	DefaultRowSize  = 5
+	MinRowSize      = 3
+	MaxRowSize      = 10
+	MinPrecision    = 53
+	MaxPrecision    = 4096
+)
+
+// Checks the settings, unset sizes fall back to the defaults
+func validateSettings(settings *model.Settings) error {
+	if err := validateVictory(settings.Victory); err != nil {
+		return err
+	}
+	if err := validatePacks(settings.Packs); err != nil {
+		return err
+	}
+
+	if settings.HandSize == 0 {
+		settings.HandSize = DefaultHandSize
+	}
+	if settings.HandSize < MinHandSize || settings.HandSize > MaxHandSize {
+		return fmt.Errorf("hand size must be %d..%d, got %d", MinHandSize, MaxHandSize, settings.HandSize)
+	}
+
+	if settings.RowSize == 0 {
+		settings.RowSize = DefaultRowSize
+	}
+	if settings.RowSize < MinRowSize || settings.RowSize > MaxRowSize {
+		return fmt.Errorf("row size must be %d..%d, got %d", MinRowSize, MaxRowSize, settings.RowSize)
+	}
+
+	if settings.Precision == 0 {
+		settings.Precision = model.DefaultPrec
+	}
+	if settings.Precision < MinPrecision || settings.Precision > MaxPrecision {
+		return fmt.Errorf("precision must be %d..%d bits, got %d", MinPrecision, MaxPrecision, settings.Precision)
+	}
+
+	switch settings.FailurePolicy {
+	case "":
+		settings.FailurePolicy = model.FailRefund
+	case model.FailRefund, model.FailDiscard, model.FailAbort:
+	default:
+		return fmt.Errorf("unknown failure policy %s", settings.FailurePolicy)
+	}
+
+	return nil
+}
+
+// Internal version (no lock), rows are still empty in the lobby so they are simply rebuilt
+func (g *Game) applySettings(settings model.Settings) error {
+	if err := validateSettings(&settings); err != nil {
+		return err
+	}
+
+	g.State.Settings = settings
+	for i := range g.State.Numbers {
+		if len(g.State.Numbers[i]) != settings.RowSize {
+			g.State.Numbers[i] = NewNumberRow(settings.RowSize)
+		}
+	}
+	return nil
+}
+
+// Sets up the game before anyone joins, for server side configuration
+func (g *Game) Configure(settings model.Settings) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.State.Phase != model.PhaseLobby {
+		return fmt.Errorf("the game has already started")
+	}
+	if err := g.applySettings(settings); err != nil {
+		return err
+	}
+	recorded := g.State.Settings
+	g.record(model.Event{Type: model.EventSettingsUpdated, Player: -1, Settings: &recorded})
+	return nil
+}
+
+// Internal version (no lock)
+func (g *Game) checkPlaying() error {
+	switch g.State.Phase {
+	case model.PhasePlaying:
+		return nil
+	case model.PhaseLobby:
+		return fmt.Errorf("the game has not started yet")
+	default:
+		return fmt.Errorf("the game is over")
+	}
+}
+
+// Internal version (no lock)
+func (g *Game) checkLobby(playerID int) error {
+	if g.State.Phase != model.PhaseLobby {
+		return fmt.Errorf("the game has already started")
+	}
+	if playerID < 0 || playerID >= len(g.State.Players) {
+		return fmt.Errorf("unknown player %d", playerID)
+	}
+	return nil
+}
+
+// API: Marks the player as (un)ready in the lobby
+func (g *Game) SetReady(playerID int, ready bool) (*model.GameState, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkLobby(playerID); err != nil {
+		return nil, err
+	}
+
+	g.State.Ready[playerID] = ready
+
+	value := 0
+	if ready {
+		value = 1
+	}
+	g.record(model.Event{Type: model.EventReady, Player: playerID, Value: value})
+
+	return g.copyState(), nil
+}
+
+// API: Replaces the game settings, only the host can do this in the lobby
+func (g *Game) UpdateSettings(playerID int, settings model.Settings) (*model.GameState, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkLobby(playerID); err != nil {
+		return nil, err
+	}
+	if playerID != hostPlayer {
+		return nil, fmt.Errorf("only the host can change the settings")
+	}
+	if err := g.applySettings(settings); err != nil {
+		return nil, err
+	}
+	recorded := g.State.Settings
+	g.record(model.Event{Type: model.EventSettingsUpdated, Player: playerID, Settings: &recorded})
+
+	// everyone has to agree to the new settings again
+	for i := range g.State.Ready {
+		g.State.Ready[i] = false
+	}
+
+	return g.copyState(), nil
+}
+
+// API: Starts the game once everybody is ready, builds the deck and deals the first hands
+func (g *Game) StartGame(playerID int) (*model.GameState, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkLobby(playerID); err != nil {
+		return nil, err
+	}
+	if playerID != hostPlayer {
+		return nil, fmt.Errorf("only the host can start the game")
+	}
+	for i, ready := range g.State.Ready {
+		if !ready {
+			return nil, fmt.Errorf("@%s is not ready yet", g.State.Players[i].Name)
+		}
+	}
+
+	// the deck is built from the packs chosen in the lobby
+	if err := g.loadCards(); err != nil {
+		return nil, err
+	}
+
+	g.State.Phase = model.PhasePlaying
+	g.State.Turn = 0
+	for i := range g.State.Done {
+		g.State.Done[i] = false
+	}
+	g.restockCards()
+	g.record(model.Event{Type: model.EventGameStarted, Player: playerID})
+
+	return g.copyState(), nil
+}
+
+// Returns the current phase of the game
+func (g *Game) Phase() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.State.Phase
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Resolves the queue on a copy of vgs, card by card. A card only changes the
+// result if it applies cleanly, otherwise the settings' failure policy decides
+// what happens to it. Cards a counter card took off the queue are skipped.
+// vgs itself is never modified.
+func (g *Game) resolveQueue(vgs *model.GameState) *model.GameState {
+	policy := vgs.Settings.FailurePolicy
+	working := cloneState(vgs)
+	order := ResolutionOrder(working)
+	resolved := make([]model.Resolution, 0, len(order))
+
+	for _, cardIndex := range order {
+		// a countered card is already in the used pile, vgs still knows its owner
+		result := model.Resolution{Card: cardIndex, Owner: vgs.Cards[cardIndex].Owner}
+		if !dequeue(working, cardIndex) {
+			result.Outcome = model.OutcomeCountered
+			resolved = append(resolved, result)
+			continue
+		}
+
+		scratch := cloneState(working)
+		err := g.ApplyCard(scratch, cardIndex)
+		if err == nil {
+			working = scratch
+			result.Outcome = model.OutcomeApplied
+			resolved = append(resolved, result)
+			continue
+		}
+		result.Error = err.Error()
+
+		switch policy {
+		case model.FailDiscard:
+			working.Cards[cardIndex].Owner = -2
+			working.Cards[cardIndex].Inputs = nil
+			result.Outcome = model.OutcomeDiscarded
+		case model.FailAbort:
+			result.Outcome = model.OutcomeAborted
+			return abortQueue(vgs, order, result)
+		default:
+			// cards in hand carry no inputs
+			working.Cards[cardIndex].Inputs = []int{}
+			result.Outcome = model.OutcomeRefunded
+		}
+		resolved = append(resolved, result)
+	}
+
+	working.Queue = nil
+	working.Resolved = resolved
+	return working
+}
+
+// Takes a card off the queue as it resolves, so a card reference can only
+// target the cards still waiting. False if a counter card already took it off.
+func dequeue(vgs *model.GameState, cardIndex int) bool {
+	pos := queuePosition(vgs, cardIndex)
+	if pos == -1 {
+		return false
+	}
+	vgs.Queue = append(vgs.Queue[:pos:pos], vgs.Queue[pos+1:]...)
+	return true
+}
+
+// Undoes the whole queue, every card goes back to its owner
+func abortQueue(vgs *model.GameState, order []int, failed model.Resolution) *model.GameState {
+	aborted := cloneState(vgs)
+	resolved := make([]model.Resolution, 0, len(order))
+	for _, cardIndex := range order {
+		if cardIndex == failed.Card {
+			resolved = append(resolved, failed)
+		} else {
+			resolved = append(resolved, model.Resolution{
+				Card:    cardIndex,
+				Owner:   aborted.Cards[cardIndex].Owner,
+				Outcome: model.OutcomeRefunded,
+			})
+		}
+		aborted.Cards[cardIndex].Inputs = []int{}
+	}
+
+	aborted.Queue = nil
+	aborted.Resolved = resolved
+	return aborted
+}
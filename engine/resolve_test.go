@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Fills the player's row with the values, the rest of the row stays empty
+func setRow(g *Game, player int, values ...int64) {
+	for i := range g.State.Numbers[player] {
+		g.State.Numbers[player][i] = NewNumber()
+	}
+	for i, v := range values {
+		g.State.Numbers[player][i] = model.IntNumber(v, false, model.DefaultPrec)
+	}
+}
+
+// Queues the card permanently
+func queue(t *testing.T, g *Game, player, cardIndex int, inputs ...int) {
+	t.Helper()
+	if inputs == nil {
+		inputs = []int{}
+	}
+	if _, err := g.ProcessMove(player, cardIndex, inputs, true); err != nil {
+		t.Fatalf("queueing card %d of player %d: %v", cardIndex, player, err)
+	}
+}
+
+// Ends the turn of every player, returns the state after the last one
+func endTurn(t *testing.T, g *Game) *model.GameState {
+	t.Helper()
+	var state *model.GameState
+	for p := range g.State.Players {
+		var err error
+		if state, err = g.ProcessNextTurn(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return state
+}
+
+func outcomes(resolved []model.Resolution) map[int]string {
+	result := map[int]string{}
+	for _, r := range resolved {
+		result[r.Card] = r.Outcome
+	}
+	return result
+}
+
+// ann's subtraction zeroes the number bob inverts, bob's inverse only fails
+// once the queue resolves, bob's addition after it applies cleanly
+type failingQueue struct {
+	sub, inv, add int
+	before        [][]model.Number
+}
+
+func queueFailing(t *testing.T, policy string) (*Game, failingQueue) {
+	t.Helper()
+	g := startedGame(t)
+	g.State.Settings.FailurePolicy = policy
+	setRow(g, 0, 3, 3, 10)
+	setRow(g, 1, 4)
+
+	q := failingQueue{
+		sub: giveCard(t, g, "SUBTRACT", 0),
+		inv: giveCard(t, g, "INVERSE", 1),
+		add: giveCard(t, g, "ADD", 1),
+	}
+	queue(t, g, 0, q.sub, 0, 1, 0, 0)
+	queue(t, g, 1, q.inv, 0, 1)
+	queue(t, g, 1, q.add, 0, 2, 1, 0)
+
+	for _, row := range g.State.Numbers {
+		copied := make([]model.Number, len(row))
+		for i := range row {
+			copied[i] = row[i].Clone()
+		}
+		q.before = append(q.before, copied)
+	}
+	return g, q
+}
+
+func TestFailureRefund(t *testing.T) {
+	g, q := queueFailing(t, model.FailRefund)
+	state := endTurn(t, g)
+
+	got := outcomes(state.Resolved)
+	want := map[int]string{q.sub: model.OutcomeApplied, q.inv: model.OutcomeRefunded, q.add: model.OutcomeApplied}
+	for card, outcome := range want {
+		if got[card] != outcome {
+			t.Errorf("card %d: %s, want %s", card, got[card], outcome)
+		}
+	}
+	if card := g.State.Cards[q.inv]; card.Owner != 1 || len(card.Inputs) != 0 {
+		t.Errorf("the refunded inverse should be back in bob's hand without inputs, got owner %d inputs %v", card.Owner, card.Inputs)
+	}
+	for _, r := range state.Resolved {
+		if r.Card == q.inv && r.Error == "" {
+			t.Errorf("the refunded card should report its error")
+		}
+	}
+	// 10 + 4, the cards after the failed one still apply
+	if n := g.State.Numbers[0][2]; n.String() != "14" {
+		t.Errorf("ann's third number is %s, want 14", n.String())
+	}
+}
+
+func TestFailureDiscard(t *testing.T) {
+	g, q := queueFailing(t, model.FailDiscard)
+	state := endTurn(t, g)
+
+	got := outcomes(state.Resolved)
+	if got[q.inv] != model.OutcomeDiscarded || got[q.sub] != model.OutcomeApplied || got[q.add] != model.OutcomeApplied {
+		t.Errorf("outcomes %v, want the inverse discarded and the rest applied", got)
+	}
+	if owner := g.State.Cards[q.inv].Owner; owner != -2 {
+		t.Errorf("the discarded inverse has owner %d, want the used pile", owner)
+	}
+	if n := g.State.Numbers[0][2]; n.String() != "14" {
+		t.Errorf("ann's third number is %s, want 14", n.String())
+	}
+}
+
+func TestFailureAbort(t *testing.T) {
+	g, q := queueFailing(t, model.FailAbort)
+	state := endTurn(t, g)
+
+	got := outcomes(state.Resolved)
+	want := map[int]string{q.sub: model.OutcomeRefunded, q.inv: model.OutcomeAborted, q.add: model.OutcomeRefunded}
+	for card, outcome := range want {
+		if got[card] != outcome {
+			t.Errorf("card %d: %s, want %s", card, got[card], outcome)
+		}
+	}
+	for p, row := range q.before {
+		for i := range row {
+			if got := g.State.Numbers[p][i]; got.Mark != row[i].Mark || got.String() != row[i].String() {
+				t.Errorf("number %d of player %d is %s, want it untouched (%s)", i, p, got.String(), row[i].String())
+			}
+		}
+	}
+	for card, owner := range map[int]int{q.sub: 0, q.inv: 1, q.add: 1} {
+		if g.State.Cards[card].Owner != owner {
+			t.Errorf("card %d has owner %d, want it back with %d", card, g.State.Cards[card].Owner, owner)
+		}
+	}
+	if len(g.State.Queue) != 0 {
+		t.Errorf("the queue is still %v", g.State.Queue)
+	}
+}
+
+// bob counters ann's addition
+func queueCounter(t *testing.T) (g *Game, add, counter int) {
+	t.Helper()
+	g = startedGame(t)
+	setRow(g, 0, 3, 4)
+	add = giveCard(t, g, "ADD", 0)
+	counter = giveCard(t, g, "COUNTEREXAMPLE", 1)
+	queue(t, g, 0, add, 0, 0, 0, 1)
+	queue(t, g, 1, counter, add)
+	return g, add, counter
+}
+
+func TestCounterResolvesFirstAndCancelsItsTarget(t *testing.T) {
+	g, add, counter := queueCounter(t)
+	state := endTurn(t, g)
+
+	if len(state.Resolved) != 2 || state.Resolved[0].Card != counter || state.Resolved[1].Card != add {
+		t.Fatalf("resolved %+v, want the counter before its target", state.Resolved)
+	}
+	if state.Resolved[0].Outcome != model.OutcomeApplied || state.Resolved[1].Outcome != model.OutcomeCountered {
+		t.Errorf("outcomes %+v, want the counter applied and the addition countered", state.Resolved)
+	}
+	if state.Resolved[1].Owner != 0 {
+		t.Errorf("the countered card is reported for player %d, want ann", state.Resolved[1].Owner)
+	}
+	if n := g.State.Numbers[0][0]; n.String() != "3" {
+		t.Errorf("the countered addition was applied, ann's first number is %s", n.String())
+	}
+	if owner := g.State.Cards[add].Owner; owner != -2 {
+		t.Errorf("the countered card has owner %d, want the used pile", owner)
+	}
+}
+
+func TestCounterOnWithdrawnCardIsRefunded(t *testing.T) {
+	g, add, counter := queueCounter(t)
+	if _, err := g.WithdrawCard(0, add); err != nil {
+		t.Fatal(err)
+	}
+	// ann queues another card the counter must not fall on
+	other := giveCard(t, g, "SUBTRACT", 0)
+	queue(t, g, 0, other, 0, 1, 0, 0)
+	state := endTurn(t, g)
+
+	got := outcomes(state.Resolved)
+	if got[counter] != model.OutcomeRefunded || got[other] != model.OutcomeApplied {
+		t.Errorf("outcomes %v, want the counter refunded and the subtraction applied", got)
+	}
+	if _, ok := got[add]; ok {
+		t.Errorf("the withdrawn card resolved: %v", got)
+	}
+	if owner := g.State.Cards[add].Owner; owner != 0 {
+		t.Errorf("the withdrawn card has owner %d, want it in ann's hand", owner)
+	}
+	if owner := g.State.Cards[counter].Owner; owner != 1 {
+		t.Errorf("the refunded counter has owner %d, want it in bob's hand", owner)
+	}
+	// 4 - 3
+	if n := g.State.Numbers[0][1]; n.String() != "1" {
+		t.Errorf("ann's second number is %s, want 1", n.String())
+	}
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Everything needed to bring a game back after a restart
+type Snapshot struct {
+	State   *model.GameState
+	RNG     []byte // position of the random source, not part of the state's JSON
+	Journal []model.Event
+}
+
+// API: Captures the game as it is right now
+func (g *Game) Snapshot() (*Snapshot, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	rng, err := g.State.RNG.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save the random source: %v", err)
+	}
+	return &Snapshot{
+		State:   g.copyState(),
+		RNG:     rng,
+		Journal: append([]model.Event(nil), g.journal...),
+	}, nil
+}
+
+// Rebuilds a game from a snapshot, dice rolls and deck draws carry on where they stopped
+func Restore(s *Snapshot) (*Game, error) {
+	if s == nil || s.State == nil {
+		return nil, fmt.Errorf("the snapshot has no game state")
+	}
+
+	state := cloneState(s.State)
+	state.RNG = new(model.RNG)
+	if err := state.RNG.UnmarshalBinary(s.RNG); err != nil {
+		return nil, fmt.Errorf("failed to restore the random source: %v", err)
+	}
+
+	return &Game{
+		State:   state,
+		journal: append([]model.Event(nil), s.Journal...),
+	}, nil
+}
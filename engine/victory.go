@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Checks a single victory condition, returns the winners if it is met
+type VictoryCheck func(gs *model.GameState, cond model.VictoryCondition) (winners []int, met bool)
+
+var victoryChecks = map[string]VictoryCheck{
+	model.VictoryTarget:       checkTarget,
+	model.VictoryHighestSum:   checkHighestSum,
+	model.VictoryLastStanding: checkLastStanding,
+}
+
+// Registers a custom victory condition type. The registry is not locked, call
+// it from init before any game starts.
+func RegisterVictoryCondition(kind string, check VictoryCheck) {
+	victoryChecks[kind] = check
+}
+
+// First player to hold the target number wins
+func TargetNumber(target int64) model.VictoryCondition {
+	return model.VictoryCondition{Type: model.VictoryTarget, Target: target}
+}
+
+// Highest row sum wins once the given number of turns have elapsed
+func HighestSum(turns int) model.VictoryCondition {
+	return model.VictoryCondition{Type: model.VictoryHighestSum, Turns: turns}
+}
+
+// Last player with at least one non-null number wins
+func LastStanding() model.VictoryCondition {
+	return model.VictoryCondition{Type: model.VictoryLastStanding}
+}
+
+// Sum of the player's non-null numbers
+func RowSum(gs *model.GameState, player int) *big.Float {
+	sum := new(big.Float)
+	for _, num := range gs.Numbers[player] {
+		if num.Mark != "n" && num.Value != nil {
+			sum.Add(sum, num.Value)
+		}
+	}
+	return sum
+}
+
+func checkTarget(gs *model.GameState, cond model.VictoryCondition) ([]int, bool) {
+	target := model.IntNumber(cond.Target, true, model.DefaultPrec)
+	winners := []int{}
+	for p := range gs.Numbers {
+		for _, num := range gs.Numbers[p] {
+			if num.Mark != "n" && num.Value != nil && num.Cmp(&target) == 0 {
+				winners = append(winners, p)
+				break
+			}
+		}
+	}
+	return winners, len(winners) > 0
+}
+
+func checkHighestSum(gs *model.GameState, cond model.VictoryCondition) ([]int, bool) {
+	if gs.Turn < cond.Turns || len(gs.Players) == 0 {
+		return nil, false
+	}
+
+	var best *big.Float
+	winners := []int{}
+	for p := range gs.Players {
+		sum := RowSum(gs, p)
+		switch {
+		case best == nil || sum.Cmp(best) > 0:
+			best = sum
+			winners = []int{p}
+		case sum.Cmp(best) == 0:
+			winners = append(winners, p)
+		}
+	}
+	return winners, true
+}
+
+// Only checked once every player had at least one turn, rows start out null
+func checkLastStanding(gs *model.GameState, cond model.VictoryCondition) ([]int, bool) {
+	if len(gs.Players) < 2 || gs.Turn < len(gs.Players) {
+		return nil, false
+	}
+
+	alive := []int{}
+	for p := range gs.Numbers {
+		for _, num := range gs.Numbers[p] {
+			if num.Mark != "n" {
+				alive = append(alive, p)
+				break
+			}
+		}
+	}
+	// nobody left is a draw
+	return alive, len(alive) <= 1
+}
+
+// Internal version (no lock)
+func (g *Game) checkVictory() error {
+	for _, cond := range g.State.Settings.Victory {
+		check, ok := victoryChecks[cond.Type]
+		if !ok {
+			return fmt.Errorf("unknown victory condition %s", cond.Type)
+		}
+
+		winners, met := check(g.State, cond)
+		if !met {
+			continue
+		}
+
+		g.State.Phase = model.PhaseFinished
+		g.State.Winners = winners
+		g.State.Standings = standings(g.State, winners)
+		return nil
+	}
+	return nil
+}
+
+// Ranks winners first, then everyone else by row sum
+func standings(gs *model.GameState, winners []int) []model.Standing {
+	won := make([]bool, len(gs.Players))
+	for _, p := range winners {
+		won[p] = true
+	}
+
+	result := make([]model.Standing, len(gs.Players))
+	for p, player := range gs.Players {
+		result[p] = model.Standing{
+			Player: p,
+			Name:   player.Name,
+			Score:  RowSum(gs, p),
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if won[a.Player] != won[b.Player] {
+			return won[a.Player]
+		}
+		return a.Score.Cmp(b.Score) > 0
+	})
+
+	for i := range result {
+		result[i].Rank = i + 1
+		if i == 0 {
+			continue
+		}
+		prev := result[i-1]
+		if won[prev.Player] == won[result[i].Player] && (won[prev.Player] || prev.Score.Cmp(result[i].Score) == 0) {
+			result[i].Rank = prev.Rank
+		}
+	}
+
+	return result
+}
+
+// Replaces the victory conditions of the game
+func (g *Game) SetVictoryConditions(conds ...model.VictoryCondition) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.State.Settings.Victory = append([]model.VictoryCondition(nil), conds...)
+}
+
+// Checks that every condition is of a known type and has what its type uses.
+// A target of 0 is taken as unset, an empty slot would not count but a zero
+// card would win on the spot.
+func validateVictory(conds []model.VictoryCondition) error {
+	for _, cond := range conds {
+		if _, ok := victoryChecks[cond.Type]; !ok {
+			return fmt.Errorf("unknown victory condition %s", cond.Type)
+		}
+		switch cond.Type {
+		case model.VictoryTarget:
+			if cond.Target == 0 {
+				return fmt.Errorf("%s needs a non-zero target", cond.Type)
+			}
+		case model.VictoryHighestSum:
+			if cond.Turns <= 0 {
+				return fmt.Errorf("%s needs a positive number of turns, got %d", cond.Type, cond.Turns)
+			}
+		}
+	}
+	return nil
+}
+
+// Reports whether a victory condition has ended the game
+func (g *Game) IsOver() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.State.Phase == model.PhaseFinished
+}
@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+func TestValidateVictory(t *testing.T) {
+	tests := []struct {
+		cond model.VictoryCondition
+		ok   bool
+	}{
+		{TargetNumber(1729), true},
+		{TargetNumber(-3), true},
+		{TargetNumber(0), false},
+		{HighestSum(10), true},
+		{HighestSum(0), false},
+		{HighestSum(-1), false},
+		{LastStanding(), true},
+		{model.VictoryCondition{Type: "NOPE"}, false},
+	}
+	for _, tt := range tests {
+		err := validateVictory([]model.VictoryCondition{tt.cond})
+		if (err == nil) != tt.ok {
+			t.Errorf("%+v: got %v, want ok %v", tt.cond, err, tt.ok)
+		}
+	}
+}
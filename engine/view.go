@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Card index of a queued card -> true
+func queuedCards(gs *model.GameState) map[int]bool {
+	queued := make(map[int]bool, len(gs.Queue))
+	for _, index := range gs.Queue {
+		queued[index] = true
+	}
+	return queued
+}
+
+// Projection of the game state for one player (-1 for spectators). Card
+// indices stay the same, but the deck and the other players' hands are face
+// down, other players' queued inputs, password hashes and the seed are removed.
+// Cards in the hand and the queue carry their input schema.
+// Only the parts that change are copied, the rest is shared with gs.
+func ViewFor(gs *model.GameState, playerID int) *model.GameState {
+	if gs == nil {
+		return nil
+	}
+
+	view := *gs
+	// the seed and the random source predict every dice roll and deck draw
+	view.Seed = 0
+	view.RNG = nil
+
+	view.Players = make([]model.Player, len(gs.Players))
+	for i, p := range gs.Players {
+		view.Players[i] = model.Player{Name: p.Name}
+	}
+
+	// spectators share -1 with the deck, they own no cards
+	own := func(card model.Card) bool { return playerID >= 0 && card.Owner == playerID }
+	queued := queuedCards(gs)
+	view.Cards = make([]model.Card, len(gs.Cards))
+	for i, card := range gs.Cards {
+		switch {
+		case own(card), card.Owner == -2:
+			// own hand and used cards
+		case queued[i]:
+			// everybody sees which card was queued, not what it targets
+			card.Inputs = nil
+		default:
+			view.Cards[i] = model.Card{Owner: card.Owner, Hidden: true}
+			continue
+		}
+		if own(card) || queued[i] {
+			card.Schema, _ = model.InputSpecs(card.InputsReq)
+		}
+		view.Cards[i] = card
+	}
+
+	return &view
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+func viewState() *model.GameState {
+	return &model.GameState{
+		Seed:    42,
+		Players: []model.Player{{Name: "ann"}, {Name: "bob"}},
+		Cards: []model.Card{
+			{ID: "ADD-1", Name: "Add", Method: "ADD", Owner: -1, InputsReq: "AnUn"},
+			{ID: "ADD-2", Name: "Add", Method: "ADD", Owner: 0, InputsReq: "AnUn"},
+			{ID: "ADD-3", Name: "Add", Method: "ADD", Owner: 1, InputsReq: "AnUn"},
+			{ID: "ADD-4", Name: "Add", Method: "ADD", Owner: 1, InputsReq: "AnUn", Inputs: []int{0, 1, 1, 2}},
+			{ID: "ADD-5", Name: "Add", Method: "ADD", Owner: -2, InputsReq: "AnUn"},
+		},
+		Queue: []int{3},
+	}
+}
+
+func TestViewForSpectator(t *testing.T) {
+	view := ViewFor(viewState(), -1)
+
+	if view.Seed != 0 {
+		t.Errorf("seed is visible: %d", view.Seed)
+	}
+	for i, want := range []bool{true, true, true, false, false} {
+		card := view.Cards[i]
+		if card.Hidden != want {
+			t.Errorf("card %d (owner %d): hidden = %v, want %v", i, card.Owner, card.Hidden, want)
+		}
+		if want && (card.ID != "" || card.Method != "" || card.Schema != nil) {
+			t.Errorf("card %d is face down but shows %+v", i, card)
+		}
+	}
+	if view.Cards[3].Inputs != nil {
+		t.Errorf("queued inputs are visible: %v", view.Cards[3].Inputs)
+	}
+}
+
+func TestViewForPlayer(t *testing.T) {
+	gs := viewState()
+	view := ViewFor(gs, 0)
+
+	for i, want := range []bool{true, false, true, false, false} {
+		if view.Cards[i].Hidden != want {
+			t.Errorf("card %d (owner %d): hidden = %v, want %v", i, gs.Cards[i].Owner, view.Cards[i].Hidden, want)
+		}
+	}
+	if len(view.Cards[1].Schema) != 4 || len(view.Cards[3].Schema) != 4 {
+		t.Errorf("hand and queue should carry their schema")
+	}
+	if view.Cards[3].Inputs != nil {
+		t.Errorf("another player's queued inputs are visible: %v", view.Cards[3].Inputs)
+	}
+	if gs.Cards[3].Inputs == nil || gs.Seed != 42 {
+		t.Errorf("ViewFor changed the game state")
+	}
+
+	// the owner of the queued card still sees its inputs
+	if view := ViewFor(gs, 1); view.Cards[3].Inputs == nil {
+		t.Errorf("own queued inputs are hidden")
+	}
+}
+
+// The same hidden index is a different card in another game
+func TestHiddenIndexDoesNotRevealTheCard(t *testing.T) {
+	deal := func(seed int64) *Game {
+		g := NewSeeded("test", seed)
+		for _, name := range []string{"ann", "bob"} {
+			player, _, err := g.AddPlayer(name, "hash")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := g.SetReady(player, true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := g.StartGame(0); err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	a, b := deal(1), deal(2)
+
+	same := 0
+	for i := range a.State.Cards {
+		if a.State.Cards[i].Method == b.State.Cards[i].Method {
+			same++
+		}
+	}
+	if same == len(a.State.Cards) {
+		t.Fatalf("both games have their %d cards in the same order", same)
+	}
+
+	// bob's hand is face down for ann, and its indices do not hold the same
+	// cards in the other game
+	view := ViewFor(a.State, 0)
+	matches, hidden := 0, 0
+	for i, card := range view.Cards {
+		if card.Owner != 1 {
+			continue
+		}
+		hidden++
+		if !card.Hidden || card.ID != "" {
+			t.Errorf("card %d of bob's hand is visible to ann", i)
+		}
+		if a.State.Cards[i].Method == b.State.Cards[i].Method {
+			matches++
+		}
+	}
+	if hidden == 0 || matches == hidden {
+		t.Errorf("bob's %d hidden cards are the same cards in another game", hidden)
+	}
+}
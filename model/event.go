@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Journal event types
+const (
+	EventGameCreated     = "GAME_CREATED"
+	EventPlayerJoined    = "PLAYER_JOINED"
+	EventReady           = "READY"
+	EventSettingsUpdated = "SETTINGS_UPDATED"
+	EventGameStarted     = "GAME_STARTED"
+	EventCardQueued      = "CARD_QUEUED"
+	EventCardWithdrawn   = "CARD_WITHDRAWN"
+	EventDiceRolled      = "DICE_ROLLED"
+	EventTurnEnded       = "TURN_ENDED" // a single player finished their turn
+	EventQueueResolved   = "QUEUE_RESOLVED"
+	EventTurnAdvanced    = "TURN_ADVANCED"
+	EventGameOver        = "GAME_OVER"
+)
+
+// Single entry of the append-only game journal, only the fields relevant to Type are set
+type Event struct {
+	Seq      int
+	Time     time.Time
+	Type     string
+	Turn     int       // turn the event happened in
+	Player   int       // -1 when no player is involved
+	Name     string    `json:",omitempty"` // game id or player name
+	Seed     int64     `json:",omitempty"`
+	Deck     string    `json:",omitempty"` // fingerprint of the deck the game was created with
+	Card     int       `json:",omitempty"`
+	CardID   string    `json:",omitempty"` // ID of Card, replays find the card by it
+	Inputs   []int     `json:",omitempty"`
+	Targets  []string  `json:",omitempty"` // IDs of the cards the c inputs name, in order
+	Slot     int       `json:",omitempty"`
+	Value    int       `json:",omitempty"` // dice value, 1/0 for ready
+	Order    []int     `json:",omitempty"` // card indices in the order they resolved
+	Winners  []int     `json:",omitempty"`
+	Settings *Settings `json:",omitempty"`
+}
@@ -0,0 +1,219 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expression operators, leaves are numbers and named constants
+const (
+	ExprNum   = "NUM"
+	ExprConst = "CONST"
+
+	OpAdd   = "+"
+	OpSub   = "-"
+	OpMul   = "*"
+	OpQuo   = "/"
+	OpPow   = "^"
+	OpNeg   = "NEG"
+	OpAbs   = "ABS"
+	OpFact  = "FACT"
+	OpSqrt  = "SQRT"
+	OpRoot  = "ROOT" // x, degree
+	OpExp   = "EXP"
+	OpLn    = "LN"
+	OpLog10 = "LOG10"
+	OpLog   = "LOG" // x, base
+	OpSin   = "SIN"
+	OpCos   = "COS"
+	OpTan   = "TAN"
+)
+
+// How a number was derived, shared between numbers so it must not be modified
+type Expr struct {
+	Op   string
+	Text string  `json:",omitempty"` // value of a number, name of a constant
+	Args []*Expr `json:",omitempty"`
+}
+
+// Numbers whose expression has more nodes show their value instead, see
+// Number.Expression. Numbers share expressions, without a cap a long game
+// sends and saves ever bigger trees.
+const MaxExprNodes = 64
+
+// Counts the nodes of the tree, stops once it is past limit
+func (e *Expr) nodes(limit int) int {
+	count := 1
+	for _, arg := range e.Args {
+		if count > limit {
+			break
+		}
+		count += arg.nodes(limit - count)
+	}
+	return count
+}
+
+func NumExpr(text string) *Expr {
+	return &Expr{Op: ExprNum, Text: text}
+}
+
+// Named constant: "pi", "e", "phi" or "tau"
+func ConstExpr(name string) *Expr {
+	return &Expr{Op: ExprConst, Text: name}
+}
+
+func NewExpr(op string, args ...*Expr) *Expr {
+	return &Expr{Op: op, Args: args}
+}
+
+var constSymbols = map[string][2]string{
+	"pi":  {"π", `\pi`},
+	"e":   {"e", "e"},
+	"phi": {"φ", `\varphi`},
+	"tau": {"τ", `\tau`},
+}
+
+var funcNames = map[string][2]string{
+	OpExp:   {"exp", ""},
+	OpLn:    {"ln", `\ln`},
+	OpLog10: {"log10", `\log_{10}`},
+	OpSin:   {"sin", `\sin`},
+	OpCos:   {"cos", `\cos`},
+	OpTan:   {"tan", `\tan`},
+}
+
+// Binding strength, decides where parentheses are needed
+func (e *Expr) precedence() int {
+	switch e.Op {
+	case OpAdd, OpSub:
+		return 1
+	case OpMul, OpQuo:
+		return 2
+	case OpNeg:
+		return 3
+	case OpPow:
+		return 4
+	case ExprNum:
+		if strings.HasPrefix(e.Text, "-") {
+			return 3
+		}
+		if strings.Contains(e.Text, "/") {
+			return 2
+		}
+	}
+	return 5
+}
+
+// Wraps the argument in parentheses if it binds looser than min, a negative
+// right operand is always wrapped ("a - (-b)")
+func (e *Expr) arg(i int, min int, paren func(string) string, format func(*Expr) string) string {
+	a := e.Args[i]
+	s := format(a)
+	if a.precedence() < min || (i > 0 && a.precedence() == 3) {
+		return paren(s)
+	}
+	return s
+}
+
+func (e *Expr) String() string {
+	if e == nil {
+		return ""
+	}
+	paren := func(s string) string { return "(" + s + ")" }
+	str := func(x *Expr) string { return x.String() }
+	p := e.precedence()
+
+	switch e.Op {
+	case ExprNum:
+		return e.Text
+	case ExprConst:
+		if sym, ok := constSymbols[e.Text]; ok {
+			return sym[0]
+		}
+		return e.Text
+	case OpAdd, OpSub:
+		return e.arg(0, p, paren, str) + " " + e.Op + " " + e.arg(1, p+1, paren, str)
+	case OpMul:
+		return e.arg(0, p, paren, str) + "·" + e.arg(1, p+1, paren, str)
+	case OpQuo:
+		return e.arg(0, p, paren, str) + "/" + e.arg(1, p+1, paren, str)
+	case OpPow:
+		return e.arg(0, p+1, paren, str) + "^" + e.arg(1, p, paren, str)
+	case OpNeg:
+		return "-" + e.arg(0, p+1, paren, str)
+	case OpAbs:
+		return "|" + e.Args[0].String() + "|"
+	case OpFact:
+		return e.arg(0, 5, paren, str) + "!"
+	case OpSqrt:
+		return "sqrt(" + e.Args[0].String() + ")"
+	case OpRoot:
+		return fmt.Sprintf("root%s(%s)", e.Args[1].String(), e.Args[0].String())
+	case OpLog:
+		return fmt.Sprintf("log%s(%s)", e.Args[1].String(), e.Args[0].String())
+	}
+	if name, ok := funcNames[e.Op]; ok {
+		return name[0] + "(" + e.Args[0].String() + ")"
+	}
+	return e.Op
+}
+
+func (e *Expr) LaTeX() string {
+	if e == nil {
+		return ""
+	}
+	paren := func(s string) string { return `\left(` + s + `\right)` }
+	tex := func(x *Expr) string { return x.LaTeX() }
+	p := e.precedence()
+
+	switch e.Op {
+	case ExprNum:
+		return numLaTeX(e.Text)
+	case ExprConst:
+		if sym, ok := constSymbols[e.Text]; ok {
+			return sym[1]
+		}
+		return e.Text
+	case OpAdd, OpSub:
+		return e.arg(0, p, paren, tex) + " " + e.Op + " " + e.arg(1, p+1, paren, tex)
+	case OpMul:
+		return e.arg(0, p, paren, tex) + ` \cdot ` + e.arg(1, p+1, paren, tex)
+	case OpQuo:
+		return `\frac{` + e.Args[0].LaTeX() + "}{" + e.Args[1].LaTeX() + "}"
+	case OpPow:
+		return "{" + e.arg(0, 5, paren, tex) + "}^{" + e.Args[1].LaTeX() + "}"
+	case OpNeg:
+		return "-" + e.arg(0, p+1, paren, tex)
+	case OpAbs:
+		return `\left|` + e.Args[0].LaTeX() + `\right|`
+	case OpFact:
+		return e.arg(0, 5, paren, tex) + "!"
+	case OpSqrt:
+		return `\sqrt{` + e.Args[0].LaTeX() + "}"
+	case OpRoot:
+		return `\sqrt[` + e.Args[1].LaTeX() + "]{" + e.Args[0].LaTeX() + "}"
+	case OpExp:
+		return "e^{" + e.Args[0].LaTeX() + "}"
+	case OpLog:
+		return `\log_{` + e.Args[1].LaTeX() + "}" + paren(e.Args[0].LaTeX())
+	}
+	if name, ok := funcNames[e.Op]; ok {
+		return name[1] + paren(e.Args[0].LaTeX())
+	}
+	return e.Op
+}
+
+// Fractions and exponent notation of a number literal
+func numLaTeX(text string) string {
+	if num, den, ok := strings.Cut(text, "/"); ok {
+		sign := ""
+		if strings.HasPrefix(num, "-") {
+			sign, num = "-", num[1:]
+		}
+		return sign + `\frac{` + num + "}{" + den + "}"
+	}
+	if mant, exp, ok := strings.Cut(text, "e"); ok {
+		return mant + ` \times 10^{` + strings.TrimPrefix(exp, "+") + "}"
+	}
+	return text
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Doubling a number shares its expression twice, the tree would double
+// with every step without the cap
+func TestExpressionIsCapped(t *testing.T) {
+	x := IntNumber(1, true, DefaultPrec)
+	for i := 0; i < 200; i++ {
+		y := x.Clone()
+		x.Add(&y)
+	}
+
+	if nodes := x.Expr.nodes(1 << 20); nodes > 2*MaxExprNodes+1 {
+		t.Errorf("the expression has %d nodes, want at most %d", nodes, 2*MaxExprNodes+1)
+	}
+	data, err := json.Marshal(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) > 64<<10 {
+		t.Errorf("the number encodes to %d bytes", len(data))
+	}
+	if x.String() != "1606938044258990275541962092341162602522202993782792835301376" {
+		t.Errorf("1 doubled 200 times is %s", x.String())
+	}
+}
+
+func TestSmallExpressionIsKept(t *testing.T) {
+	a, b := IntNumber(2, true, DefaultPrec), IntNumber(3, true, DefaultPrec)
+	a.Mul(&b)
+	if got := a.Expression().String(); got != "2·3" {
+		t.Errorf("2 * 3 shows as %q", got)
+	}
+}
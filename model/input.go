@@ -0,0 +1,84 @@
+package model
+
+import "fmt"
+
+// Input kinds, one per letter of InputsReq except the player prefixes
+const (
+	InputDie    = "DIE"    // d
+	InputPlayer = "PLAYER" // p, U or A, see Players
+	InputNumber = "NUMBER" // n, slot in the row of the player before it
+	InputCard   = "CARD"   // c, index of a card another player has queued
+	InputMin    = "MIN"    // X, lower bound of the VALUE after it
+	InputMax    = "MAX"    // Y, upper bound of the VALUE after it
+	InputValue  = "VALUE"  // i, between the MIN and MAX before it
+)
+
+// Players a PLAYER input can name
+const (
+	PlayersAny      = "ANY"      // p
+	PlayersOwner    = "OWNER"    // U, the card's owner
+	PlayersDefender = "DEFENDER" // A, the player whose turn it is
+)
+
+// Describes one input of a card, so clients do not have to decode InputsReq
+type InputSpec struct {
+	Kind    string
+	Label   string
+	Players string `json:",omitempty"` // PLAYER only
+	Min     *int   `json:",omitempty"` // fixed bounds, DIE only
+	Max     *int   `json:",omitempty"`
+	// earlier inputs this one depends on: the player of a NUMBER, the MIN and
+	// MAX of a VALUE
+	DependsOn []int `json:",omitempty"`
+}
+
+// Builds the input schema of an InputsReq, see Card. n must follow a player
+// input (p, U or A) and i must follow the X and Y bounds.
+func InputSpecs(req string) ([]InputSpec, error) {
+	specs := make([]InputSpec, len(req))
+	for i := 0; i < len(req); i++ {
+		spec := &specs[i]
+		switch req[i] {
+		case 'd':
+			lo, hi := 1, 6
+			*spec = InputSpec{Kind: InputDie, Label: "die", Min: &lo, Max: &hi}
+		case 'p':
+			*spec = InputSpec{Kind: InputPlayer, Label: "any player", Players: PlayersAny}
+		case 'U':
+			*spec = InputSpec{Kind: InputPlayer, Label: "you", Players: PlayersOwner}
+		case 'A':
+			*spec = InputSpec{Kind: InputPlayer, Label: "defending player", Players: PlayersDefender}
+		case 'n':
+			if i == 0 || specs[i-1].Kind != InputPlayer {
+				return nil, fmt.Errorf("input %d (n) must follow a player input", i)
+			}
+			*spec = InputSpec{Kind: InputNumber, Label: numberLabel(specs[i-1]), DependsOn: []int{i - 1}}
+		case 'c':
+			*spec = InputSpec{Kind: InputCard, Label: "card another player queued"}
+		case 'X':
+			*spec = InputSpec{Kind: InputMin, Label: "minimum"}
+		case 'Y':
+			*spec = InputSpec{Kind: InputMax, Label: "maximum"}
+		case 'i':
+			if i < 2 || specs[i-2].Kind != InputMin || specs[i-1].Kind != InputMax {
+				return nil, fmt.Errorf("input %d (i) must follow X and Y", i)
+			}
+			label := fmt.Sprintf("value between the %s and the %s", specs[i-2].Label, specs[i-1].Label)
+			*spec = InputSpec{Kind: InputValue, Label: label, DependsOn: []int{i - 2, i - 1}}
+		default:
+			return nil, fmt.Errorf("unknown input type %q at %d", req[i], i)
+		}
+	}
+	return specs, nil
+}
+
+// Label of a NUMBER, named after the PLAYER it belongs to
+func numberLabel(player InputSpec) string {
+	switch player.Players {
+	case PlayersOwner:
+		return "your number"
+	case PlayersDefender:
+		return "number of the " + player.Label
+	}
+	return "number of the player"
+}
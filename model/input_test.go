@@ -0,0 +1,38 @@
+package model
+
+import "testing"
+
+func TestInputSpecs(t *testing.T) {
+	specs, err := InputSpecs("AnUnpnc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := []string{"defending player", "number of the defending player", "you", "your number", "any player", "number of the player", "card another player queued"}
+	for i, spec := range specs {
+		if spec.Label != labels[i] {
+			t.Errorf("input %d: label %q, want %q", i, spec.Label, labels[i])
+		}
+	}
+	if deps := specs[3].DependsOn; len(deps) != 1 || deps[0] != 2 {
+		t.Errorf("your number depends on %v, want [2]", deps)
+	}
+
+	specs, err = InputSpecs("dXYi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := specs[3]; v.Kind != InputValue || v.Label != "value between the minimum and the maximum" || len(v.DependsOn) != 2 || v.DependsOn[0] != 1 {
+		t.Errorf("value input is %+v", v)
+	}
+}
+
+func TestInputSpecsErrors(t *testing.T) {
+	for _, req := range []string{"n", "nA", "dn", "cn", "i", "Xi", "YXi", "dYi", "Aq"} {
+		if specs, err := InputSpecs(req); err == nil {
+			t.Errorf("InputSpecs(%q) = %+v, want an error", req, specs)
+		}
+	}
+	if specs, err := InputSpecs(""); err != nil || len(specs) != 0 {
+		t.Errorf("InputSpecs(\"\") = %v, %v", specs, err)
+	}
+}
@@ -3,19 +3,25 @@ package model
 import "math/big"
 
 type Card struct {
-	// ID          string // Unique Identifier for every card, even if a dublicate id is different
+	ID          string // unique for every copy and stable for the whole game, method and copy number (ADD-3)
 	Name        string
 	Description string
 	Type        string
 	Method      string // Defines what method in code will be taken
+	Precedence  int    // queued cards with higher precedence resolve first
+	Pack        string // Core0, Core1, Core3 or Core
 	Owner       int    // -1: deck, -2: used, User.ID: owner
 	Inputs      []int  // length depends on the card
 	InputsReq   string // string with each character signifying input number type.
+	Hidden      bool   `json:",omitempty"` // face down in a player's view, only Owner is kept
+	Formula     string `json:",omitempty"` // effect of a FORMULA card, see cards/formula
+	// InputsReq decoded by InputSpecs, only filled in a player's view of the hand and the queue
+	Schema []InputSpec `json:",omitempty"`
 	// InputsReq explained:
 	// d: dice (int)
 	// p: player (int)
 	// n: number (int)
-	// c: card, id (string) -> doesn't work yet (we have to figure out something as we can't accept strings anymore)
+	// c: card (int), index of a card another player has queued
 	// U: makes next digit user's
 	// A: Makes next digit attacked one
 	// X: minimum for the input (int)
@@ -31,19 +37,101 @@ type Player struct {
 
 type Number struct {
 	Value *big.Float
+	Rat   *big.Rat `json:",omitempty"` // exact value in exact mode, nil once the number went through an inexact card
+	Expr  *Expr    `json:",omitempty"` // how the number was derived, nil for a plain value
 	Mark  string
 	// n: null
 	// F: fibonacci
 	// I: immune
 }
 
+// Game phases
+const (
+	PhaseLobby    = "LOBBY"    // players join, ready up and change settings
+	PhasePlaying  = "PLAYING"  // cards are dealt and moves are accepted
+	PhaseFinished = "FINISHED" // a victory condition was met
+)
+
+// Victory condition types
+const (
+	VictoryTarget       = "TARGET"        // first to hold Target in their row
+	VictoryHighestSum   = "HIGHEST_SUM"   // highest row sum once Turns have elapsed
+	VictoryLastStanding = "LAST_STANDING" // last player with non-null numbers
+)
+
+// Describes one way of winning the game, checked after every turn
+type VictoryCondition struct {
+	Type   string
+	Target int64 // used by TARGET
+	Turns  int   // used by HIGHEST_SUM
+}
+
+// Final result of a single player
+type Standing struct {
+	Player int
+	Name   string
+	Score  *big.Float // sum of the non-null numbers in the row
+	Rank   int        // 1 is the best, equal scores share a rank
+}
+
+// What happens when a queued card fails to apply
+const (
+	FailRefund  = "REFUND"  // skip the card and give it back to its owner
+	FailDiscard = "DISCARD" // skip the card and put it in the used pile
+	FailAbort   = "ABORT"   // undo the whole queue and give every card back
+)
+
+// Outcome of a queued card once the queue is resolved
+const (
+	OutcomeApplied   = "APPLIED"
+	OutcomeRefunded  = "REFUNDED"
+	OutcomeDiscarded = "DISCARDED"
+	OutcomeAborted   = "ABORTED"
+	OutcomeCountered = "COUNTERED" // a counter card took it off the queue before it resolved
+)
+
+// Result of a single queued card, in resolution order
+type Resolution struct {
+	Card    int
+	Owner   int
+	Outcome string
+	Error   string `json:",omitempty"`
+}
+
+// Group of cards the deck can be built from
+type Pack struct {
+	Name  string
+	Size  int      // number of cards including copies
+	Cards []string // card names
+}
+
+// Per-game configuration
+type Settings struct {
+	Victory  []VictoryCondition // checked in order, the first one met ends the game
+	Packs    []string           // packs the deck is built from, empty means every pack
+	HandSize int                // cards every player is restocked to (4..10)
+	RowSize  int                // number slots per player (3..10)
+	// FailurePolicy decides what happens to a queued card that fails to apply
+	FailurePolicy string
+	Exact         bool // keep numbers as exact fractions wherever the cards allow it
+	Precision     uint // bits of precision for inexact numbers (53..4096)
+}
+
 // Main Game Object
 type GameState struct {
-	GameID  string
-	Players []Player
-	Cards   []Card
-	Numbers [][5]Number
-	Done    []bool
-	Queue   []int // stores cardIndex and every time the move is finished, we apply all the cards and cleane the data in them, marking them as used.
-	Turn    int   // total turns elapsed; current player = Turn % len(Players)
+	GameID    string
+	Phase     string
+	Seed      int64 // seed of RNG, enough to reproduce the game from its moves
+	RNG       *RNG  `json:"-"`
+	Settings  Settings
+	Players   []Player
+	Ready     []bool // lobby ready-check, one per player
+	Cards     []Card
+	Numbers   [][]Number // one row of Settings.RowSize slots per player
+	Done      []bool
+	Queue     []int        // stores cardIndex and every time the move is finished, we apply all the cards and cleane the data in them, marking them as used.
+	Resolved  []Resolution // how the last queue was resolved, in order
+	Turn      int          // total turns elapsed; current player = Turn % len(Players)
+	Winners   []int
+	Standings []Standing
 }
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Default precision in bits of the floats, also used for the float kept next to
+// an exact number when it has none yet
+const DefaultPrec = 256
+
+// Number holding an integer, exact when the game runs in exact mode
+func IntNumber(v int64, exact bool, prec uint) Number {
+	if exact {
+		return RatNumber(new(big.Rat).SetInt64(v), prec)
+	}
+	return FloatNumber(new(big.Float).SetPrec(prec).SetInt64(v))
+}
+
+// Same as IntNumber for big integers
+func BigIntNumber(v *big.Int, exact bool, prec uint) Number {
+	if exact {
+		return RatNumber(new(big.Rat).SetInt(v), prec)
+	}
+	return FloatNumber(new(big.Float).SetPrec(prec).SetInt(v))
+}
+
+// Exact fraction
+func RatNumber(r *big.Rat, prec uint) Number {
+	n := Number{Value: new(big.Float).SetPrec(prec)}
+	n.SetRat(r)
+	return n
+}
+
+// Inexact number
+func FloatNumber(f *big.Float) Number {
+	return Number{Value: f}
+}
+
+// Reports whether the number is held as an exact fraction
+func (n *Number) IsExact() bool {
+	return n.Rat != nil
+}
+
+// Precision of the float value in bits
+func (n *Number) Prec() uint {
+	if n.Value == nil || n.Value.Prec() == 0 {
+		return DefaultPrec
+	}
+	return n.Value.Prec()
+}
+
+// Sets an exact value, Value follows as its float approximation. The number
+// becomes a plain value, set Expr afterwards to keep its history
+func (n *Number) SetRat(r *big.Rat) {
+	n.Value = new(big.Float).SetPrec(n.Prec()).SetRat(r)
+	n.Rat = r
+	n.Expr = nil
+}
+
+// Sets an inexact value, the exact fraction is dropped
+func (n *Number) SetFloat(f *big.Float) {
+	n.Rat = nil
+	n.Value = f
+	n.Expr = nil
+}
+
+// Sets an integer, keeping the number exact if it was
+func (n *Number) SetInt64(v int64) {
+	if n.Rat != nil {
+		n.SetRat(new(big.Rat).SetInt64(v))
+		return
+	}
+	n.SetFloat(new(big.Float).SetPrec(n.Prec()).SetInt64(v))
+}
+
+// Empties the slot
+func (n *Number) Clear() {
+	n.Rat = nil
+	n.Value = big.NewFloat(0)
+	n.Expr = nil
+	n.Mark = "n"
+}
+
+// Deep copy
+func (n *Number) Clone() Number {
+	c := Number{Mark: n.Mark, Expr: n.Expr}
+	if n.Value != nil {
+		c.Value = new(big.Float).Set(n.Value)
+	}
+	if n.Rat != nil {
+		c.Rat = new(big.Rat).Set(n.Rat)
+	}
+	return c
+}
+
+// How the number was derived, a plain value is its own expression and so is
+// a number derived in more than MaxExprNodes steps
+func (n *Number) Expression() *Expr {
+	if n.Expr != nil && n.Expr.nodes(MaxExprNodes) <= MaxExprNodes {
+		return n.Expr
+	}
+	return NumExpr(n.String())
+}
+
+// n = n + x
+func (n *Number) Add(x *Number) {
+	n.combine(x, OpAdd, (*big.Rat).Add, (*big.Float).Add)
+}
+
+// n = n - x
+func (n *Number) Sub(x *Number) {
+	n.combine(x, OpSub, (*big.Rat).Sub, (*big.Float).Sub)
+}
+
+// n = n * x
+func (n *Number) Mul(x *Number) {
+	n.combine(x, OpMul, (*big.Rat).Mul, (*big.Float).Mul)
+}
+
+// n = n / x, x must not be zero
+func (n *Number) Quo(x *Number) {
+	n.combine(x, OpQuo, (*big.Rat).Quo, (*big.Float).Quo)
+}
+
+// Exact only if both sides are exact
+func (n *Number) combine(x *Number, op string,
+	ratOp func(z, a, b *big.Rat) *big.Rat,
+	floatOp func(z, a, b *big.Float) *big.Float,
+) {
+	expr := NewExpr(op, n.Expression(), x.Expression())
+	if n.Rat != nil && x.Rat != nil {
+		n.SetRat(ratOp(new(big.Rat), n.Rat, x.Rat))
+	} else {
+		floatOp(n.Value, n.Value, x.Value)
+		n.Rat = nil
+	}
+	n.Expr = expr
+}
+
+// n = -n
+func (n *Number) Neg() {
+	expr := NewExpr(OpNeg, n.Expression())
+	if n.Rat != nil {
+		n.SetRat(new(big.Rat).Neg(n.Rat))
+	} else {
+		n.Value.Neg(n.Value)
+	}
+	n.Expr = expr
+}
+
+// n = |n|
+func (n *Number) Abs() {
+	expr := NewExpr(OpAbs, n.Expression())
+	if n.Rat != nil {
+		n.SetRat(new(big.Rat).Abs(n.Rat))
+	} else {
+		n.Value.Abs(n.Value)
+	}
+	n.Expr = expr
+}
+
+// n = 1 / n, n must not be zero
+func (n *Number) Inv() {
+	expr := NewExpr(OpQuo, NumExpr("1"), n.Expression())
+	if n.Rat != nil {
+		n.SetRat(new(big.Rat).Inv(n.Rat))
+	} else {
+		n.Value.Quo(big.NewFloat(1), n.Value)
+	}
+	n.Expr = expr
+}
+
+func (n *Number) Sign() int {
+	if n.Rat != nil {
+		return n.Rat.Sign()
+	}
+	return n.Value.Sign()
+}
+
+// Compares exactly when both numbers are exact
+func (n *Number) Cmp(x *Number) int {
+	if n.Rat != nil && x.Rat != nil {
+		return n.Rat.Cmp(x.Rat)
+	}
+	return n.Value.Cmp(x.Value)
+}
+
+func (n *Number) IsInt() bool {
+	if n.Rat != nil {
+		return n.Rat.IsInt()
+	}
+	return n.Value.IsInt()
+}
+
+// Returns the integer value if the number is an integer
+func (n *Number) Int() (*big.Int, bool) {
+	if !n.IsInt() {
+		return nil, false
+	}
+	if n.Rat != nil {
+		return new(big.Int).Set(n.Rat.Num()), true
+	}
+	i, _ := n.Value.Int(nil)
+	return i, true
+}
+
+// Fraction for exact numbers ("3/4", "7"), 10 significant digits otherwise
+func (n *Number) String() string {
+	if n.Rat != nil {
+		return n.Rat.RatString()
+	}
+	if n.Value == nil {
+		return "<nil>"
+	}
+	return n.Value.Text('g', 10)
+}
+
+// The precision of the float is kept next to it, so a saved number restores
+// exactly ("Value":"3.14159...","Prec":256)
+func (n Number) MarshalJSON() ([]byte, error) {
+	type plain Number
+	var prec uint
+	if n.Value != nil {
+		prec = n.Value.Prec()
+	}
+	return json.Marshal(struct {
+		plain
+		Prec uint `json:",omitempty"`
+	}{plain(n), prec})
+}
+
+func (n *Number) UnmarshalJSON(data []byte) error {
+	type plain Number
+	var v struct {
+		plain
+		Value *string
+		Prec  uint
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*n = Number(v.plain)
+	if v.Value != nil {
+		// big.Float parses with 64 bits unless the precision is set first
+		f := new(big.Float).SetPrec(v.Prec)
+		if v.Prec == 0 {
+			f.SetPrec(DefaultPrec)
+		}
+		if _, _, err := f.Parse(*v.Value, 0); err != nil {
+			return fmt.Errorf("invalid number %q: %v", *v.Value, err)
+		}
+		n.Value = f
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package model
+
+import "math/rand/v2"
+
+// Seeded random source of a game. Cloning it gives an independent copy that
+// produces the same sequence, so previews never consume the live rolls.
+type RNG struct {
+	pcg *rand.PCG
+	r   *rand.Rand
+}
+
+func NewRNG(seed int64) *RNG {
+	pcg := rand.NewPCG(uint64(seed), uint64(seed)^0x9e3779b97f4a7c15)
+	return &RNG{pcg: pcg, r: rand.New(pcg)}
+}
+
+// Returns a random int in [0, n)
+func (rng *RNG) IntN(n int) int {
+	return rng.r.IntN(n)
+}
+
+// Randomizes the order of n elements, swap exchanges elements i and j
+func (rng *RNG) Shuffle(n int, swap func(i, j int)) {
+	rng.r.Shuffle(n, swap)
+}
+
+// Makes an independent copy at the same position in the sequence
+func (rng *RNG) Clone() *RNG {
+	if rng == nil {
+		return nil
+	}
+	pcg := *rng.pcg
+	return &RNG{pcg: &pcg, r: rand.New(&pcg)}
+}
+
+// Position in the sequence, for saving a game
+func (rng *RNG) MarshalBinary() ([]byte, error) {
+	return rng.pcg.MarshalBinary()
+}
+
+// Restores a position saved with MarshalBinary
+func (rng *RNG) UnmarshalBinary(data []byte) error {
+	pcg := new(rand.PCG)
+	if err := pcg.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	rng.pcg = pcg
+	rng.r = rand.New(pcg)
+	return nil
+}
@@ -0,0 +1,209 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/umarbektokyo/matetra-engine/cards"
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// State of the game at the start of a turn, with the events that led to it
+type Frame struct {
+	Turn   int
+	State  *model.GameState
+	Events []model.Event // events recorded since the previous frame
+}
+
+// Game rebuilt from its journal, one frame per turn
+type Replay struct {
+	GameID string
+	Seed   int64
+	Frames []Frame
+}
+
+// Reads a JSONL journal, only the last game in the file is kept
+func Load(r io.Reader) ([]model.Event, error) {
+	var events []model.Event
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e model.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		// journals are appended to, a new game starts over
+		if e.Type == model.EventGameCreated {
+			events = nil
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("journal has no game in it")
+	}
+	return events, nil
+}
+
+// Re-drives a fresh engine.Game with the recorded events
+func New(events []model.Event) (*Replay, error) {
+	if len(events) == 0 || events[0].Type != model.EventGameCreated {
+		return nil, fmt.Errorf("journal must start with %s", model.EventGameCreated)
+	}
+
+	created := events[0]
+	// journals from before deck fingerprints cannot be checked
+	deck, err := cards.Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	if created.Deck != "" && created.Deck != deck {
+		return nil, fmt.Errorf("the game was played with deck %s but this deck is %s, pass the same --cards file", created.Deck, deck)
+	}
+	game := engine.NewSeeded(created.Name, created.Seed)
+
+	r := &Replay{GameID: created.Name, Seed: created.Seed}
+	pending := []model.Event{created}
+
+	snapshot := func() {
+		state := game.CopyState()
+		r.Frames = append(r.Frames, Frame{Turn: state.Turn, State: state, Events: pending})
+		pending = nil
+	}
+
+	for _, e := range events[1:] {
+		pending = append(pending, e)
+		if err := apply(game, e); err != nil {
+			return r, fmt.Errorf("event %d (%s): %v", e.Seq, e.Type, err)
+		}
+
+		switch e.Type {
+		case model.EventGameStarted, model.EventTurnAdvanced:
+			snapshot()
+		}
+	}
+
+	// game stopped in the middle of a turn
+	if len(pending) > 0 {
+		snapshot()
+	}
+
+	return r, nil
+}
+
+// Applies a single journal event to the game, derived events are only checked
+func apply(game *engine.Game, e model.Event) error {
+	var err error
+
+	switch e.Type {
+	case model.EventPlayerJoined:
+		_, _, err = game.AddPlayer(e.Name, "")
+	case model.EventReady:
+		_, err = game.SetReady(e.Player, e.Value == 1)
+	case model.EventSettingsUpdated:
+		if e.Settings == nil {
+			return fmt.Errorf("missing settings")
+		}
+		if e.Player < 0 {
+			// configured by the server before anyone joined
+			err = game.Configure(*e.Settings)
+		} else {
+			_, err = game.UpdateSettings(e.Player, *e.Settings)
+		}
+	case model.EventGameStarted:
+		_, err = game.StartGame(e.Player)
+	case model.EventCardQueued:
+		card, inputs, cerr := cardInputs(game, e)
+		if cerr != nil {
+			return cerr
+		}
+		_, err = game.ProcessMove(e.Player, card, inputs, true)
+	case model.EventCardWithdrawn:
+		card, _, cerr := cardInputs(game, e)
+		if cerr != nil {
+			return cerr
+		}
+		_, err = game.WithdrawCard(e.Player, card)
+	case model.EventDiceRolled:
+		var state *model.GameState
+		state, err = game.ProcessDiceRoll(e.Player)
+		if err == nil {
+			rolled, _ := state.Numbers[e.Player][e.Slot].Value.Int64()
+			if int(rolled) != e.Value {
+				err = fmt.Errorf("diverged, rolled %d instead of %d", rolled, e.Value)
+			}
+		}
+	case model.EventTurnEnded:
+		_, err = game.ProcessNextTurn(e.Player)
+	case model.EventTurnAdvanced, model.EventGameOver:
+		// produced by the last TURN_ENDED, make sure we are still in sync
+		if turn := game.CopyState().Turn; turn != e.Turn {
+			err = fmt.Errorf("diverged, at turn %d instead of %d", turn, e.Turn)
+		}
+	}
+
+	return err
+}
+
+// Finds the cards an event names by their IDs, journals from before card
+// IDs only have indices
+func cardInputs(game *engine.Game, e model.Event) (int, []int, error) {
+	card := e.Card
+	if e.CardID != "" {
+		index, err := game.CardIndex(e.CardID)
+		if err != nil {
+			return 0, nil, err
+		}
+		card = index
+	}
+	if len(e.Targets) == 0 {
+		return card, e.Inputs, nil
+	}
+
+	state := game.CopyState()
+	if card < 0 || card >= len(state.Cards) {
+		return 0, nil, fmt.Errorf("there is no card %d", card)
+	}
+	inputs := append([]int(nil), e.Inputs...)
+	specs, err := model.InputSpecs(state.Cards[card].InputsReq)
+	if err != nil {
+		return 0, nil, err
+	}
+	next := 0
+	for i, spec := range specs {
+		if spec.Kind != model.InputCard || i >= len(inputs) || next >= len(e.Targets) {
+			continue
+		}
+		index, err := game.CardIndex(e.Targets[next])
+		if err != nil {
+			return 0, nil, err
+		}
+		inputs[i] = index
+		next++
+	}
+	return card, inputs, nil
+}
+
+// Returns the frame for the given turn
+func (r *Replay) StateAt(turn int) (*model.GameState, error) {
+	for _, f := range r.Frames {
+		if f.Turn == turn {
+			return f.State, nil
+		}
+	}
+	return nil, fmt.Errorf("turn %d is not in the replay", turn)
+}
@@ -0,0 +1,88 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+func TestCardInputsFollowIDs(t *testing.T) {
+	game := engine.NewSeeded("test", 1)
+	for _, name := range []string{"ann", "bob"} {
+		player, _, err := game.AddPlayer(name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		game.SetReady(player, true)
+	}
+	if _, err := game.StartGame(0); err != nil {
+		t.Fatal(err)
+	}
+
+	state := game.CopyState()
+	counter, target := -1, -1
+	for i, card := range state.Cards {
+		switch {
+		case card.Method == "COUNTEREXAMPLE" && counter == -1:
+			counter = i
+		case card.Method == "ADD" && target == -1:
+			target = i
+		}
+	}
+	if counter == -1 || target == -1 {
+		t.Fatal("the deck has no COUNTEREXAMPLE or ADD card")
+	}
+
+	// the IDs win over the recorded indices
+	e := model.Event{
+		Type:    model.EventCardQueued,
+		Card:    -5,
+		CardID:  state.Cards[counter].ID,
+		Inputs:  []int{-5},
+		Targets: []string{state.Cards[target].ID},
+	}
+	card, inputs, err := cardInputs(game, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card != counter || len(inputs) != 1 || inputs[0] != target {
+		t.Errorf("got card %d inputs %v, want card %d inputs [%d]", card, inputs, counter, target)
+	}
+
+	// journals from before card IDs keep their indices
+	old := model.Event{Type: model.EventCardQueued, Card: counter, Inputs: []int{target}}
+	if card, inputs, _ := cardInputs(game, old); card != counter || inputs[0] != target {
+		t.Errorf("got card %d inputs %v from an old event", card, inputs)
+	}
+
+	e.Targets = []string{"NOPE-1"}
+	if _, _, err := cardInputs(game, e); err == nil {
+		t.Errorf("an unknown card ID should fail")
+	}
+}
+
+func TestReplayNeedsTheSameDeck(t *testing.T) {
+	game := engine.NewSeeded("test", 1)
+	if _, _, err := game.AddPlayer("ann", ""); err != nil {
+		t.Fatal(err)
+	}
+	events := game.Journal()
+	if events[0].Deck == "" {
+		t.Fatalf("the journal does not record the deck")
+	}
+	if _, err := New(events); err != nil {
+		t.Errorf("replay with the same deck: %v", err)
+	}
+
+	events[0].Deck = "0123456789abcdef"
+	if _, err := New(events); err == nil {
+		t.Errorf("replay with another deck should fail")
+	}
+
+	// journals from before the deck was recorded still replay
+	events[0].Deck = ""
+	if _, err := New(events); err != nil {
+		t.Errorf("replay of an old journal: %v", err)
+	}
+}
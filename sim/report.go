@@ -0,0 +1,175 @@
+package sim
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// How often a card is played and how it relates to winning
+type CardStats struct {
+	Method   string  `json:"method"`
+	Name     string  `json:"name"`
+	Pack     string  `json:"pack"`
+	Dealt    int     `json:"dealt"`     // times a copy reached a hand
+	Queued   int     `json:"queued"`    // times queued, including cards that failed and came back
+	Played   int     `json:"played"`    // times applied
+	PlayRate float64 `json:"play_rate"` // Played / Dealt
+	// share of the players who played the card in a finished game that won it
+	WinRate float64 `json:"win_rate"`
+	// phi coefficient between playing the card at least once in a game and
+	// winning it, over every seat of every finished game (-1..1)
+	WinCorrelation float64 `json:"win_correlation"`
+	// mean log10 of the owner's largest number right after the card resolved
+	AvgMagnitude float64 `json:"avg_magnitude"`
+}
+
+type Report struct {
+	Games        int            `json:"games"`
+	Finished     int            `json:"finished"` // games that met a victory condition before the turn limit
+	Bots         []string       `json:"bots"`
+	AvgTurns     float64        `json:"avg_turns"`
+	MaxTurns     int            `json:"max_turns"`     // longest game
+	AvgMagnitude float64        `json:"avg_magnitude"` // mean log10 of the numbers left at the end
+	MaxMagnitude float64        `json:"max_magnitude"`
+	Wins         map[string]int `json:"wins"`  // per strategy, every winner of a draw counts
+	Cards        []CardStats    `json:"cards"` // most played first
+}
+
+// Folds the games into a report
+func newReport(cfg Config, results []gameResult) *Report {
+	report := &Report{
+		Games: len(results),
+		Bots:  cfg.Bots,
+		Wins:  map[string]int{},
+	}
+
+	turns := 0
+	magnitudes := 0.0
+	numbers := 0
+	report.MaxMagnitude = math.Inf(-1)
+	for _, result := range results {
+		turns += result.turns
+		report.MaxTurns = max(report.MaxTurns, result.turns)
+		for _, m := range result.numbers {
+			magnitudes += m
+			report.MaxMagnitude = math.Max(report.MaxMagnitude, m)
+		}
+		numbers += len(result.numbers)
+		if result.finished {
+			report.Finished++
+			for _, winner := range result.winners {
+				report.Wins[cfg.Bots[winner]]++
+			}
+		}
+	}
+	report.AvgTurns = float64(turns) / float64(len(results))
+	report.AvgMagnitude = ratio(magnitudes, float64(numbers))
+	if numbers == 0 {
+		report.MaxMagnitude = 0
+	}
+
+	for k, card := range results[0].cards {
+		stats := CardStats{Method: card.Method, Name: card.Name, Pack: card.Pack}
+
+		// 2x2 table of (played the card, won the game) over every seat
+		var table [2][2]float64
+		resolved := 0.0
+		resolvedCount := 0
+		for _, result := range results {
+			stats.Dealt += result.dealt[k]
+			stats.Queued += result.queued[k]
+			for _, m := range result.resolved[k] {
+				resolved += m
+				resolvedCount++
+			}
+
+			won := make([]bool, len(cfg.Bots))
+			for _, winner := range result.winners {
+				won[winner] = true
+			}
+			for seat, played := range result.played {
+				stats.Played += played[k]
+				if !result.finished {
+					continue
+				}
+				table[b2i(played[k] > 0)][b2i(won[seat])]++
+			}
+		}
+
+		stats.PlayRate = ratio(float64(stats.Played), float64(stats.Dealt))
+		stats.WinRate = ratio(table[1][1], table[1][0]+table[1][1])
+		stats.WinCorrelation = phi(table)
+		stats.AvgMagnitude = ratio(resolved, float64(resolvedCount))
+		report.Cards = append(report.Cards, stats)
+	}
+
+	sort.Slice(report.Cards, func(i, j int) bool {
+		a, b := report.Cards[i], report.Cards[j]
+		if a.PlayRate != b.PlayRate {
+			return a.PlayRate > b.PlayRate
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// Correlation of two yes/no variables from their 2x2 table, 0 when either never varies
+func phi(t [2][2]float64) float64 {
+	den := (t[1][0] + t[1][1]) * (t[0][0] + t[0][1]) * (t[0][1] + t[1][1]) * (t[0][0] + t[1][0])
+	if den == 0 {
+		return 0
+	}
+	return (t[1][1]*t[0][0] - t[1][0]*t[0][1]) / math.Sqrt(den)
+}
+
+// a / b, 0 when b is 0
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// One row per card, the game-wide numbers are only in the JSON report
+func (r *Report) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	header := []string{"method", "name", "pack", "dealt", "queued", "played", "play_rate", "win_rate", "win_correlation", "avg_magnitude"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 4, 64)
+	}
+	for _, c := range r.Cards {
+		row := []string{
+			c.Method, c.Name, c.Pack,
+			strconv.Itoa(c.Dealt), strconv.Itoa(c.Queued), strconv.Itoa(c.Played),
+			f(c.PlayRate), f(c.WinRate), f(c.WinCorrelation), f(c.AvgMagnitude),
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,236 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/umarbektokyo/matetra-engine/bot"
+	"github.com/umarbektokyo/matetra-engine/engine"
+	"github.com/umarbektokyo/matetra-engine/model"
+)
+
+// Games still running after this many turns are stopped and counted as unfinished
+const DefaultMaxTurns = 200
+
+// What to simulate
+type Config struct {
+	Games    int
+	Bots     []string // strategy of every seat, at least two
+	Settings model.Settings
+	Seed     int64 // game i is seeded with Seed+i, so a run can be repeated
+	MaxTurns int   // 0 means DefaultMaxTurns
+	Workers  int   // games played at the same time, 0 means one per CPU
+}
+
+// Everything that happened in one game, folded into the report afterwards
+type gameResult struct {
+	finished bool
+	turns    int // also the last turn whose queue was counted
+	winners  []int
+	dealt    map[string]int        // kind -> copies that reached a hand, used cards go back into the deck
+	queued   map[string]int        // kind -> times queued, refunded cards can be queued again
+	played   []map[string]int      // seat -> kind -> times applied
+	resolved map[string][]float64  // kind -> owner's magnitude after the card resolved
+	numbers  []float64             // magnitudes of the numbers left at the end
+	cards    map[string]model.Card // kind -> a copy of the card, for its method, name and pack
+	err      error
+}
+
+// Plays cfg.Games bot-only games in process and reports on them
+func Run(cfg Config) (*Report, error) {
+	if len(cfg.Bots) < 2 {
+		return nil, fmt.Errorf("a simulation needs at least two bots, got %d", len(cfg.Bots))
+	}
+	for _, kind := range cfg.Bots {
+		if _, err := bot.NewStrategy(kind, 0); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Games < 1 {
+		return nil, fmt.Errorf("a simulation needs at least one game, got %d", cfg.Games)
+	}
+	if cfg.MaxTurns == 0 {
+		cfg.MaxTurns = DefaultMaxTurns
+	}
+	if cfg.Workers == 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+
+	results := make([]gameResult, cfg.Games)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = playGame(cfg, i)
+			}
+		}()
+	}
+	for i := 0; i < cfg.Games; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, result := range results {
+		if result.err != nil {
+			return nil, fmt.Errorf("game %d: %v", i, result.err)
+		}
+	}
+	return newReport(cfg, results), nil
+}
+
+// Plays a single game between the configured bots
+func playGame(cfg Config, index int) gameResult {
+	seed := cfg.Seed + int64(index)
+	game := engine.NewSeeded(fmt.Sprintf("sim-%d", index), seed)
+	settings := cfg.Settings
+	settings.Victory = append([]model.VictoryCondition(nil), cfg.Settings.Victory...)
+	settings.Packs = append([]string(nil), cfg.Settings.Packs...)
+	if err := game.Configure(settings); err != nil {
+		return gameResult{err: err}
+	}
+
+	result := gameResult{
+		dealt:    map[string]int{},
+		queued:   map[string]int{},
+		played:   make([]map[string]int, len(cfg.Bots)),
+		resolved: map[string][]float64{},
+		cards:    map[string]model.Card{},
+	}
+
+	bots := make([]*bot.Bot, len(cfg.Bots))
+	for seat, kind := range cfg.Bots {
+		b, err := bot.Join(game, fmt.Sprintf("%s-%d", kind, seat), kind, seed*int64(len(cfg.Bots))+int64(seat))
+		if err != nil {
+			return gameResult{err: err}
+		}
+		bots[seat] = b
+		result.played[seat] = map[string]int{}
+	}
+
+	recorder := func(seat int) func(bot.Action) {
+		return func(action bot.Action) {
+			result.record(seat, action)
+		}
+	}
+
+	for _, b := range bots {
+		if err := b.Act(game, recorder(b.Player)); err != nil {
+			return gameResult{err: err}
+		}
+	}
+	if _, err := game.StartGame(0); err != nil {
+		return gameResult{err: err}
+	}
+
+	for {
+		state := game.CopyState()
+		if state.Phase != model.PhasePlaying || state.Turn >= cfg.MaxTurns {
+			break
+		}
+
+		var due *bot.Bot
+		for _, b := range bots {
+			if b.Due(state) {
+				due = b
+				break
+			}
+		}
+		if due == nil {
+			return gameResult{err: fmt.Errorf("no bot can move on turn %d", state.Turn)}
+		}
+		if err := due.Act(game, recorder(due.Player)); err != nil {
+			return gameResult{err: err}
+		}
+	}
+
+	state := game.CopyState()
+	result.finished = state.Phase == model.PhaseFinished
+	result.turns = state.Turn
+	result.winners = state.Winners
+	for _, card := range state.Cards {
+		result.cards[kind(card)] = card
+		if card.Owner >= 0 {
+			result.dealt[kind(card)]++
+		}
+	}
+	for p := range state.Numbers {
+		for _, num := range state.Numbers[p] {
+			if m, ok := magnitude(num); ok {
+				result.numbers = append(result.numbers, m)
+			}
+		}
+	}
+	return result
+}
+
+// Cards are told apart by method, formula cards share theirs so their name
+// comes along
+func kind(card model.Card) string {
+	if card.Formula != "" {
+		return card.Method + ":" + card.Name
+	}
+	return card.Method
+}
+
+// Counts a bot's step
+func (result *gameResult) record(seat int, action bot.Action) {
+	state := action.State
+	switch action.Type {
+	case bot.ActionPlay:
+		result.queued[kind(state.Cards[action.Play.Card])]++
+	case bot.ActionEnd:
+		// the queue only resolves once the last player ends the turn
+		if state.Turn == result.turns {
+			return
+		}
+		result.turns = state.Turn
+		for _, r := range state.Resolved {
+			k := kind(state.Cards[r.Card])
+			// refunded and aborted cards are back in the hand, still dealt once
+			if r.Outcome == model.OutcomeDiscarded || r.Outcome == model.OutcomeCountered {
+				result.dealt[k]++
+			}
+			if r.Outcome != model.OutcomeApplied {
+				continue
+			}
+			result.dealt[k]++
+			result.played[r.Owner][k]++
+			result.resolved[k] = append(result.resolved[k], rowMagnitude(state, r.Owner))
+		}
+	}
+}
+
+// Order of magnitude (log10 of the absolute value), false for null numbers, zero and infinity
+func magnitude(num model.Number) (float64, bool) {
+	if num.Mark == "n" || num.Value == nil || num.Value.Sign() == 0 {
+		return 0, false
+	}
+	if num.Value.IsInf() {
+		return 0, false
+	}
+	mant := new(big.Float)
+	exp := num.Value.MantExp(mant)
+	m, _ := mant.Float64()
+	return (math.Log2(math.Abs(m)) + float64(exp)) * math.Log10(2), true
+}
+
+// Magnitude of the largest number in the player's row, 0 for an empty row
+func rowMagnitude(gs *model.GameState, player int) float64 {
+	largest := math.Inf(-1)
+	for _, num := range gs.Numbers[player] {
+		if m, ok := magnitude(num); ok {
+			largest = math.Max(largest, m)
+		}
+	}
+	if math.IsInf(largest, -1) {
+		return 0
+	}
+	return largest
+}
@@ -5,15 +5,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
-	"math/rand"
-	"time"
+	"slices"
 
 	"github.com/umarbektokyo/matetra-engine/model"
 )
 
 var VERSION = "0.1"
 var PORT int = 1729
-var r *rand.Rand
 var ascii string = `
                          $$\                $$\                        
                          $$ |               $$ |                       
@@ -24,10 +22,6 @@ $$ | $$ | $$ |$$  __$$ | $$ |$$\ $$   ____| $$ |$$\ $$ |     $$  __$$ |
 $$ | $$ | $$ |\$$$$$$$ | \$$$$  |\$$$$$$$\  \$$$$  |$$ |     \$$$$$$$ |
 \__| \__| \__| \_______|  \____/  \_______|  \____/ \__|      \_______|`
 
-func init() {
-	r = rand.New(rand.NewSource(time.Now().UnixNano()))
-}
-
 func Must[T any](v T, err error) T {
 	if err != nil {
 		panic(err)
@@ -53,74 +47,89 @@ func ValidateInputs(vgs *model.GameState, card *model.Card) error {
 		)
 	}
 
-	// Check input values
-	for i := range card.InputsReq {
-		val := card.Inputs[i]
-		switch card.InputsReq[i] {
-		case 'd':
-			if val < 1 || val > 6 {
-				return fmt.Errorf("input %d must be dice (1..6), got %v", i, val)
-			}
-
-		case 'p':
-			if val < 0 || val >= len(vgs.Players) {
-				return fmt.Errorf("input %d must be player index, got %v", i, val)
-			}
+	// Check input values against the schema
+	specs, err := model.InputSpecs(card.InputsReq)
+	if err != nil {
+		return fmt.Errorf("%s has an invalid InputsReq: %v", card.Method, err)
+	}
+	for i, spec := range specs {
+		if err := validateInput(vgs, card, spec, card.Inputs[i]); err != nil {
+			return fmt.Errorf("input %d (%s) %v", i, spec.Label, err)
+		}
+	}
 
-		case 'U':
-			if val < 0 || val >= len(vgs.Players) {
-				return fmt.Errorf("input %d must be player index, got %v", i, val)
-			}
-			if val != card.Owner {
-				return fmt.Errorf("input %d must be your own index (%v), got %v", i, card.Owner, val)
-			}
+	return nil
+}
 
-		case 'A':
-			if val < 0 || val >= len(vgs.Players) {
-				return fmt.Errorf("input %d must be player index, got %v", i, val)
-			}
+// Checks a single input, the error reads after "input i (label)"
+func validateInput(vgs *model.GameState, card *model.Card, spec model.InputSpec, val int) error {
+	switch spec.Kind {
+	case model.InputDie:
+		if val < *spec.Min || val > *spec.Max {
+			return fmt.Errorf("must be %d..%d, got %v", *spec.Min, *spec.Max, val)
+		}
 
-			if val != (vgs.Turn % len(vgs.Players)) {
-				return fmt.Errorf("input %d must be index of defending player, got %v", i, val)
+	case model.InputPlayer:
+		if val < 0 || val >= len(vgs.Players) {
+			return fmt.Errorf("must be player index 0..%d, got %v", len(vgs.Players)-1, val)
+		}
+		switch spec.Players {
+		case model.PlayersOwner:
+			if val != card.Owner {
+				return fmt.Errorf("must be your own index (%v), got %v", card.Owner, val)
 			}
-
-		case 'n':
-			if val < 0 || val > 4 {
-				return fmt.Errorf("input %d must be number index 0..4, got %v", i, val)
+		case model.PlayersDefender:
+			if defender := vgs.Turn % len(vgs.Players); val != defender {
+				return fmt.Errorf("must be index of defending player (%v), got %v", defender, val)
 			}
+		}
 
-		case 'c':
-			fmt.Println("You forgot to implement this!!")
-
-		case 'i':
-			X := card.Inputs[i-2]
-			Y := card.Inputs[i-1]
-			if val < X || val > Y {
-				return fmt.Errorf("input %d must be in range of %d..%d, got %d", i, X, Y, val)
-			}
+	case model.InputNumber:
+		player := card.Inputs[spec.DependsOn[0]]
+		last := len(vgs.Numbers[player]) - 1
+		if val < 0 || val > last {
+			return fmt.Errorf("must be number index 0..%d, got %v", last, val)
+		}
+		if vgs.Numbers[player][val].Mark == "I" {
+			return fmt.Errorf("is immune this turn (number %d of player %d)", val, player)
 		}
 
-	}
+	case model.InputCard:
+		if val < 0 || val >= len(vgs.Cards) {
+			return fmt.Errorf("must be card index 0..%d, got %v", len(vgs.Cards)-1, val)
+		}
+		target := vgs.Cards[val]
+		if !slices.Contains(vgs.Queue, val) {
+			return fmt.Errorf("must be a queued card, %s is not queued", target.ID)
+		}
+		if target.Owner == card.Owner {
+			return fmt.Errorf("must be another player's card, %s is your own", target.ID)
+		}
 
-	// Check for immunity
-	for i := 0; i < len(card.InputsReq); i++ {
-		if card.InputsReq[i] == 'n' {
-			player := card.Inputs[i-1]
-			index := card.Inputs[i]
-			if vgs.Numbers[player][index].Mark == "I" {
-				return fmt.Errorf("number %d of player %d is immune this turn", index, player)
-			}
+	case model.InputValue:
+		X := card.Inputs[spec.DependsOn[0]]
+		Y := card.Inputs[spec.DependsOn[1]]
+		if val < X || val > Y {
+			return fmt.Errorf("must be in range of %d..%d, got %d", X, Y, val)
 		}
 	}
-
 	return nil
 }
 
-func RollDice(sides int) int {
-	roll := r.Intn(sides) + 1
+// Rolls using the game's own random source
+func RollDice(vgs *model.GameState, sides int) int {
+	roll := vgs.RNG.IntN(sides) + 1
 	return roll
 }
 
+// Working precision of the game in bits
+func Precision(vgs *model.GameState) uint {
+	if vgs.Settings.Precision == 0 {
+		return model.DefaultPrec
+	}
+	return vgs.Settings.Precision
+}
+
 func CheckCardMark(vgs *model.GameState, playerIndex int, numberIndex int) error {
 	if vgs.Numbers[playerIndex][numberIndex].Mark == "n" {
 		return fmt.Errorf("cannot use null card")